@@ -0,0 +1,3 @@
+module github.com/ONLYOFFICE/onlyoffice-integration-adapters
+
+go 1.22
@@ -0,0 +1,71 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import "fmt"
+
+// Role is the access level of a user opening a document.
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"
+	RoleEditor   Role = "editor"
+	RoleReviewer Role = "reviewer"
+)
+
+// ResolveAccess derives the editor mode and permissions from the extension
+// capability and the user role. Only editable and loss-editable files are
+// opened in the edit mode, and only for editors and reviewers: a viewer never
+// gets edit, a reviewer gets review and comment but not edit. Fillable files
+// are opened in the edit mode with fillForms but not edit for editors.
+func ResolveAccess(ext string, role Role) (mode string, perms Permissions, err error) {
+	return resolveAccess(defaultFileUtility(), ext, role)
+}
+
+func resolveAccess(util OnlyofficeFileUtility, ext string, role Role) (string, Permissions, error) {
+	switch role {
+	case RoleViewer, RoleEditor, RoleReviewer:
+	default:
+		return "", Permissions{}, fmt.Errorf("%w: %q", ErrUnknownRole, role)
+	}
+
+	if _, err := util.GetFileType(ext); err != nil {
+		return "", Permissions{}, err
+	}
+
+	perms := Permissions{Copy: true, Download: true, Print: true}
+	if role == RoleEditor && util.IsFillable(ext) {
+		perms.FillForms = true
+		return OnlyofficeEditMode, perms, nil
+	}
+
+	editable := util.IsExtensionEditable(ext) || util.IsExtensionLossEditable(ext)
+	if !editable || role == RoleViewer {
+		return OnlyofficeViewMode, perms, nil
+	}
+
+	perms.Comment = true
+	perms.Review = true
+	if role == RoleEditor {
+		perms.Edit = true
+		perms.FillForms = true
+	}
+
+	return OnlyofficeEditMode, perms, nil
+}
@@ -0,0 +1,71 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResolveAccess(t *testing.T) {
+	var (
+		readOnly = Permissions{Copy: true, Download: true, Print: true}
+		review   = Permissions{Comment: true, Copy: true, Download: true, Print: true, Review: true}
+		fill     = Permissions{Copy: true, Download: true, FillForms: true, Print: true}
+		full     = Permissions{Comment: true, Copy: true, Download: true, Edit: true, FillForms: true, Print: true, Review: true}
+	)
+
+	tests := []struct {
+		ext   string
+		role  Role
+		mode  string
+		perms Permissions
+	}{
+		{"docx", RoleViewer, OnlyofficeViewMode, readOnly},
+		{"docx", RoleReviewer, OnlyofficeEditMode, review},
+		{"docx", RoleEditor, OnlyofficeEditMode, full},
+		{"odt", RoleViewer, OnlyofficeViewMode, readOnly},
+		{"odt", RoleReviewer, OnlyofficeEditMode, review},
+		{"odt", RoleEditor, OnlyofficeEditMode, full},
+		{"doc", RoleViewer, OnlyofficeViewMode, readOnly},
+		{"doc", RoleReviewer, OnlyofficeViewMode, readOnly},
+		{"doc", RoleEditor, OnlyofficeViewMode, readOnly},
+		{"pdf", RoleViewer, OnlyofficeViewMode, readOnly},
+		{"pdf", RoleReviewer, OnlyofficeViewMode, readOnly},
+		{"pdf", RoleEditor, OnlyofficeViewMode, readOnly},
+		{"oform", RoleViewer, OnlyofficeViewMode, readOnly},
+		{"oform", RoleReviewer, OnlyofficeViewMode, readOnly},
+		{"oform", RoleEditor, OnlyofficeEditMode, fill},
+	}
+
+	for _, test := range tests {
+		mode, perms, err := ResolveAccess(test.ext, test.role)
+		if err != nil || mode != test.mode || perms != test.perms {
+			t.Errorf("ResolveAccess(%q, %q) = %q, %+v, %v; expected %q, %+v", test.ext, test.role, mode, perms, err, test.mode, test.perms)
+		}
+	}
+
+	if _, _, err := ResolveAccess("docx", "owner"); !errors.Is(err, ErrUnknownRole) {
+		t.Errorf("expected ErrUnknownRole, got %v", err)
+	}
+
+	if _, _, err := ResolveAccess("exe", RoleEditor); !errors.Is(err, ErrOnlyofficeExtensionNotSupported) {
+		t.Errorf("expected ErrOnlyofficeExtensionNotSupported, got %v", err)
+	}
+}
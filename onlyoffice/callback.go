@@ -0,0 +1,393 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	CallbackStatusEditing        int = 1
+	CallbackStatusReadyForSave   int = 2
+	CallbackStatusSaveError      int = 3
+	CallbackStatusClosedNoChange int = 4
+	CallbackStatusForceSave      int = 6
+	CallbackStatusForceSaveError int = 7
+)
+
+// CallbackActionType is the type of a callback action.
+type CallbackActionType int
+
+const (
+	CallbackActionDisconnect CallbackActionType = 0
+	CallbackActionConnect    CallbackActionType = 1
+	CallbackActionForceSave  CallbackActionType = 2
+)
+
+// ForceSaveType tells what initiated a force save callback.
+type ForceSaveType int
+
+const (
+	ForceSaveTypeCommand    ForceSaveType = 0
+	ForceSaveTypeButton     ForceSaveType = 1
+	ForceSaveTypeTimer      ForceSaveType = 2
+	ForceSaveTypeFormSubmit ForceSaveType = 3
+)
+
+// CallbackAction is a user action reported by a callback.
+type CallbackAction struct {
+	Type   CallbackActionType `json:"type"`
+	UserID string             `json:"userid"`
+}
+
+// UnmarshalJSON accepts the type and the user id as either strings or numbers.
+func (a *CallbackAction) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type   tolerantInt    `json:"type"`
+		UserID tolerantString `json:"userid"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	a.Type = CallbackActionType(raw.Type)
+	a.UserID = string(raw.UserID)
+	return nil
+}
+
+// CallbackBody is the payload the document server posts to the callback url.
+type CallbackBody struct {
+	Actions       []CallbackAction `json:"actions,omitempty"`
+	ChangesURL    string           `json:"changesurl,omitempty"`
+	FileType      string           `json:"filetype,omitempty"`
+	ForceSaveType ForceSaveType    `json:"forcesavetype,omitempty"`
+	Key           string           `json:"key"`
+	// Size is the file size in bytes, when the document server reports it.
+	Size     *int64   `json:"size,omitempty"`
+	Status   int      `json:"status"`
+	URL      string   `json:"url,omitempty"`
+	Users    []string `json:"users,omitempty"`
+	UserData string   `json:"userdata,omitempty"`
+	Token    string   `json:"token,omitempty"`
+}
+
+// UnmarshalJSON tolerates user ids sent as numbers and a forcesavetype sent as a string.
+func (b *CallbackBody) UnmarshalJSON(data []byte) error {
+	type plain CallbackBody
+	var raw struct {
+		plain
+		ForceSaveType tolerantInt      `json:"forcesavetype"`
+		Users         []tolerantString `json:"users"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*b = CallbackBody(raw.plain)
+	b.ForceSaveType = ForceSaveType(raw.ForceSaveType)
+	b.Users = nil
+	for _, user := range raw.Users {
+		b.Users = append(b.Users, string(user))
+	}
+
+	return nil
+}
+
+// ForceSaveAction returns the action that triggered a force save, if any.
+func (b CallbackBody) ForceSaveAction() (CallbackAction, bool) {
+	for _, action := range b.Actions {
+		if action.Type == CallbackActionForceSave {
+			return action, true
+		}
+	}
+
+	return CallbackAction{}, false
+}
+
+// tolerantString decodes a JSON string or number.
+type tolerantString string
+
+func (s *tolerantString) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case string:
+		*s = tolerantString(v)
+	case float64:
+		*s = tolerantString(strconv.FormatFloat(v, 'f', -1, 64))
+	case nil:
+		*s = ""
+	default:
+		return fmt.Errorf("expected a string or a number, got %s", data)
+	}
+
+	return nil
+}
+
+// tolerantInt decodes a JSON number or a string holding an integer.
+type tolerantInt int
+
+func (i *tolerantInt) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case float64:
+		*i = tolerantInt(v)
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %s", data)
+		}
+
+		*i = tolerantInt(n)
+	case nil:
+		*i = 0
+	default:
+		return fmt.Errorf("expected an integer, got %s", data)
+	}
+
+	return nil
+}
+
+// ValidateCallbackDownloadURL must be called before fetching the url of a callback body.
+// Only http(s) urls are accepted. When allowedHosts is not empty the host has to be
+// one of them, otherwise loopback, private and link-local addresses are rejected.
+// Host names are not resolved here: without allowedHosts fetch the url with
+// NewExternalHTTPClient, which checks every address it connects to.
+// With WithRequireHTTPS only https urls are accepted.
+func ValidateCallbackDownloadURL(rawURL string, allowedHosts []string, opts ...Option) error {
+	return validateCallbackDownloadURL(rawURL, allowedHosts, newOptions(opts...).RequireHTTPS)
+}
+
+func validateCallbackDownloadURL(rawURL string, allowedHosts []string, requireHTTPS bool) error {
+	if err := validateCallbackScheme(rawURL, requireHTTPS); err != nil {
+		return err
+	}
+
+	parsed, _ := url.Parse(rawURL)
+
+	host := strings.ToLower(parsed.Hostname())
+	if len(allowedHosts) > 0 {
+		if isHostAllowed(host, allowedHosts) {
+			return nil
+		}
+
+		return ErrInvalidCallbackURL
+	}
+
+	if isInternalHost(host) {
+		return ErrInvalidCallbackURL
+	}
+
+	return nil
+}
+
+// CallbackKeyMismatchError reports a callback carrying another document's key.
+// It matches ErrCallbackKeyMismatch with errors.Is.
+type CallbackKeyMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *CallbackKeyMismatchError) Error() string {
+	return fmt.Sprintf("%s: expected %q, got %q", ErrCallbackKeyMismatch, e.Expected, e.Actual)
+}
+
+func (e *CallbackKeyMismatchError) Unwrap() error {
+	return ErrCallbackKeyMismatch
+}
+
+// VerifyCallbackKey checks that the callback belongs to the document issued with expectedKey.
+func VerifyCallbackKey(body CallbackBody, expectedKey string) error {
+	if body.Key != expectedKey {
+		return &CallbackKeyMismatchError{Expected: expectedKey, Actual: body.Key}
+	}
+
+	return nil
+}
+
+// VerifyCallback verifies the token of a callback body and replaces body with
+// the signed payload. When expectedKey is not empty the key of the signed
+// payload must match it.
+func VerifyCallback(body *CallbackBody, jwt OnlyofficeJWTManager, expectedKey string) error {
+	if body.Token == "" {
+		return ErrInvalidToken
+	}
+
+	var signed CallbackBody
+	if err := jwt.Verify(body.Token, &signed); err != nil {
+		return err
+	}
+
+	signed.Token = body.Token
+	*body = signed
+
+	if expectedKey == "" {
+		return nil
+	}
+
+	return VerifyCallbackKey(*body, expectedKey)
+}
+
+const _ConversionCallbackTokenParam = "token"
+
+// validateCallbackScheme checks that rawURL is an absolute http(s) url, or an
+// https one when requireHTTPS is set.
+func validateCallbackScheme(rawURL string, requireHTTPS bool) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return ErrInvalidCallbackURL
+	}
+
+	if parsed.Scheme != "https" && requireHTTPS {
+		return fmt.Errorf("%w: https is required", ErrInvalidCallbackURL)
+	}
+
+	return nil
+}
+
+// conversionCallbackClaims binds a conversion callback token to the callback
+// path and to the canonical query without the token itself.
+type conversionCallbackClaims struct {
+	Path      string `json:"path"`
+	Query     string `json:"query"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// BuildConversionCallbackURL returns base with a token query parameter signed
+// with jwt that expires after ttl. The token covers the path and the other
+// query parameters of base, so it cannot be replayed against another endpoint
+// or with parameters identifying another resource. Check incoming requests
+// with VerifyConversionCallbackURL. With WithRequireHTTPS only https bases are
+// accepted.
+func BuildConversionCallbackURL(base string, jwt OnlyofficeJWTManager, ttl time.Duration, opts ...Option) (string, error) {
+	if err := validateCallbackScheme(base, newOptions(opts...).RequireHTTPS); err != nil {
+		return "", err
+	}
+
+	parsed, _ := url.Parse(base)
+
+	if ttl <= 0 {
+		return "", fmt.Errorf("%w: non-positive ttl %s", ErrInvalidCallbackURL, ttl)
+	}
+
+	query := parsed.Query()
+	query.Del(_ConversionCallbackTokenParam)
+	token, err := jwt.Sign(conversionCallbackClaims{
+		Path:      parsed.EscapedPath(),
+		Query:     query.Encode(),
+		ExpiresAt: time.Now().Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	query.Set(_ConversionCallbackTokenParam, token)
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), nil
+}
+
+// VerifyConversionCallbackURL checks the token of a callback url produced by
+// BuildConversionCallbackURL. The url may be absolute or, as in the url of an
+// incoming request, only a path and query. ErrTokenExpired is returned once
+// the ttl has passed and ErrInvalidToken for missing, forged or replayed tokens
+// as well as for urls whose path or query was changed.
+func VerifyConversionCallbackURL(callbackURL string, jwt OnlyofficeJWTManager) error {
+	parsed, err := url.Parse(callbackURL)
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	query := parsed.Query()
+	token := query.Get(_ConversionCallbackTokenParam)
+	if token == "" {
+		return ErrInvalidToken
+	}
+	query.Del(_ConversionCallbackTokenParam)
+
+	var claims conversionCallbackClaims
+	if err := jwt.Verify(token, &claims); err != nil {
+		return err
+	}
+
+	if claims.ExpiresAt == 0 || claims.Path != parsed.EscapedPath() || claims.Query != query.Encode() {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
+
+// SaveAction is what an integration should do after receiving a callback.
+type SaveAction int
+
+const (
+	SaveActionNone SaveAction = iota
+	SaveActionSave
+	SaveActionForceSave
+	SaveActionCleanup
+)
+
+func (a SaveAction) String() string {
+	switch a {
+	case SaveActionSave:
+		return "save"
+	case SaveActionForceSave:
+		return "forcesave"
+	case SaveActionCleanup:
+		return "cleanup"
+	default:
+		return "none"
+	}
+}
+
+// NeedsSave reports whether the callback carries a document that has to be stored.
+func (b CallbackBody) NeedsSave() bool {
+	return (b.Status == CallbackStatusReadyForSave || b.Status == CallbackStatusForceSave) && b.URL != ""
+}
+
+// CallbackTransition decides the action for the current callback given the
+// previous one for the same document. A repeated save callback with the same
+// url is not saved twice.
+func CallbackTransition(prev, cur CallbackBody) SaveAction {
+	switch {
+	case cur.NeedsSave() && prev.NeedsSave() && prev.Status == cur.Status && prev.URL == cur.URL:
+		return SaveActionNone
+	case cur.NeedsSave() && cur.Status == CallbackStatusForceSave:
+		return SaveActionForceSave
+	case cur.NeedsSave():
+		return SaveActionSave
+	case cur.Status == CallbackStatusClosedNoChange:
+		return SaveActionCleanup
+	default:
+		return SaveActionNone
+	}
+}
@@ -0,0 +1,277 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidateCallbackDownloadURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		allowedHosts []string
+		err          error
+	}{
+		{"public host", "https://docs.example.com/cache/files/output.docx", nil, nil},
+		{"allowed host", "https://docs.example.com/cache/files/output.docx", []string{"DOCS.example.com"}, nil},
+		{"allowed internal host", "http://10.0.0.5/cache/files/output.docx", []string{"10.0.0.5"}, nil},
+		{"disallowed host", "https://evil.example.org/output.docx", []string{"docs.example.com"}, ErrInvalidCallbackURL},
+		{"internal ip", "http://169.254.169.254/latest/meta-data", nil, ErrInvalidCallbackURL},
+		{"private ip", "http://192.168.1.10/output.docx", nil, ErrInvalidCallbackURL},
+		{"loopback", "http://localhost:8080/output.docx", nil, ErrInvalidCallbackURL},
+		{"invalid scheme", "file:///etc/passwd", nil, ErrInvalidCallbackURL},
+	}
+
+	for _, test := range tests {
+		if err := ValidateCallbackDownloadURL(test.url, test.allowedHosts); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestCallbackTransition(t *testing.T) {
+	editing := CallbackBody{Key: "key", Status: CallbackStatusEditing}
+	ready := CallbackBody{Key: "key", Status: CallbackStatusReadyForSave, URL: "https://docs.example.com/output.docx"}
+	forceSaved := CallbackBody{Key: "key", Status: CallbackStatusForceSave, URL: "https://docs.example.com/forcesave.docx"}
+	closed := CallbackBody{Key: "key", Status: CallbackStatusClosedNoChange}
+
+	tests := []struct {
+		name   string
+		prev   CallbackBody
+		cur    CallbackBody
+		action SaveAction
+	}{
+		{"editing to ready", editing, ready, SaveActionSave},
+		{"editing to closed without changes", editing, closed, SaveActionCleanup},
+		{"editing to force save", editing, forceSaved, SaveActionForceSave},
+		{"force save to ready", forceSaved, ready, SaveActionSave},
+		{"duplicate ready", ready, ready, SaveActionNone},
+		{"editing to editing", editing, editing, SaveActionNone},
+		{"ready without url", editing, CallbackBody{Key: "key", Status: CallbackStatusReadyForSave}, SaveActionNone},
+	}
+
+	for _, test := range tests {
+		if action := CallbackTransition(test.prev, test.cur); action != test.action {
+			t.Errorf("%s: expected %s, got %s", test.name, test.action, action)
+		}
+	}
+
+	if !ready.NeedsSave() || !forceSaved.NeedsSave() || editing.NeedsSave() || closed.NeedsSave() {
+		t.Error("unexpected NeedsSave result")
+	}
+}
+
+func TestVerifyCallbackKey(t *testing.T) {
+	body := CallbackBody{Key: "document-1", Status: CallbackStatusReadyForSave}
+	if err := VerifyCallbackKey(body, "document-1"); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	err := VerifyCallbackKey(body, "document-2")
+	if !errors.Is(err, ErrCallbackKeyMismatch) {
+		t.Fatalf("expected ErrCallbackKeyMismatch, got %v", err)
+	}
+
+	var mismatch *CallbackKeyMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Expected != "document-2" || mismatch.Actual != "document-1" {
+		t.Errorf("expected a CallbackKeyMismatchError, got %#v", err)
+	}
+}
+
+func TestVerifyCallback(t *testing.T) {
+	manager := NewOnlyofficeJWTManager("secret")
+	token, err := manager.Sign(CallbackBody{
+		Key:    "document-1",
+		Status: CallbackStatusReadyForSave,
+		URL:    "https://docs.example.com/output.docx",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		body        CallbackBody
+		expectedKey string
+		err         error
+	}{
+		{"matching key", CallbackBody{Token: token}, "document-1", nil},
+		{"no expected key", CallbackBody{Token: token}, "", nil},
+		{"mismatching key", CallbackBody{Token: token}, "document-2", ErrCallbackKeyMismatch},
+		{"unsigned body", CallbackBody{Key: "document-1"}, "document-1", ErrInvalidToken},
+		{"forged body", CallbackBody{Key: "document-2", Token: token + "x"}, "document-2", ErrInvalidToken},
+	}
+
+	for _, test := range tests {
+		body := test.body
+		if err := VerifyCallback(&body, manager, test.expectedKey); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.err, err)
+			continue
+		}
+
+		if test.err == nil && (body.Key != "document-1" || body.URL == "") {
+			t.Errorf("%s: expected the signed payload, got %+v", test.name, body)
+		}
+	}
+}
+
+func TestValidateCallbackDownloadURLRequireHTTPS(t *testing.T) {
+	if err := ValidateCallbackDownloadURL("http://docs.example.com/output.docx", nil); err != nil {
+		t.Errorf("expected http to be accepted by default, got %v", err)
+	}
+
+	if err := ValidateCallbackDownloadURL("http://docs.example.com/output.docx", nil, WithRequireHTTPS(true)); !errors.Is(err, ErrInvalidCallbackURL) {
+		t.Errorf("expected ErrInvalidCallbackURL, got %v", err)
+	}
+
+	if err := ValidateCallbackDownloadURL("https://docs.example.com/output.docx", nil, WithRequireHTTPS(true)); err != nil {
+		t.Errorf("expected https to be accepted, got %v", err)
+	}
+}
+
+func TestCallbackBodyUnmarshal(t *testing.T) {
+	data := []byte(`{
+		"actions": [{"type": 2, "userid": "78e1e841"}, {"type": "1", "userid": 42}],
+		"forcesavetype": 1,
+		"key": "document-1",
+		"status": 6,
+		"url": "https://docs.example.com/output.docx",
+		"users": ["6d5a81d0", 42]
+	}`)
+
+	var body CallbackBody
+	if err := json.Unmarshal(data, &body); err != nil {
+		t.Fatal(err)
+	}
+
+	if body.Key != "document-1" || body.Status != CallbackStatusForceSave || body.ForceSaveType != ForceSaveTypeButton {
+		t.Errorf("unexpected callback %+v", body)
+	}
+
+	if !reflect.DeepEqual(body.Users, []string{"6d5a81d0", "42"}) {
+		t.Errorf("expected users [6d5a81d0 42], got %v", body.Users)
+	}
+
+	expected := []CallbackAction{
+		{Type: CallbackActionForceSave, UserID: "78e1e841"},
+		{Type: CallbackActionConnect, UserID: "42"},
+	}
+	if !reflect.DeepEqual(body.Actions, expected) {
+		t.Errorf("expected actions %+v, got %+v", expected, body.Actions)
+	}
+
+	if action, ok := body.ForceSaveAction(); !ok || action.UserID != "78e1e841" {
+		t.Errorf("expected a force save action by 78e1e841, got %+v, %v", action, ok)
+	}
+
+	if err := json.Unmarshal([]byte(`{"forcesavetype": "timer"}`), &body); err == nil {
+		t.Error("expected a non-numeric forcesavetype to be rejected")
+	}
+
+	if err := json.Unmarshal([]byte(`{"key": "document-2", "status": 4}`), &body); err != nil || body.Users != nil || body.Actions != nil {
+		t.Errorf("expected an empty user and action list, got %+v, %v", body, err)
+	}
+}
+
+func TestConversionCallbackURL(t *testing.T) {
+	jwt := NewOnlyofficeJWTManager("secret")
+	callbackURL, err := BuildConversionCallbackURL("https://app.example.com/conversion/done?file=42", jwt, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := url.Parse(callbackURL)
+	if err != nil || parsed.Query().Get("file") != "42" || parsed.Query().Get("token") == "" {
+		t.Fatalf("unexpected callback url %q", callbackURL)
+	}
+
+	if err := VerifyConversionCallbackURL(callbackURL, jwt); err != nil {
+		t.Errorf("expected the callback url to verify, got %v", err)
+	}
+
+	received := httptest.NewRequest("POST", callbackURL, nil)
+	if err := VerifyConversionCallbackURL(received.URL.String(), jwt); err != nil {
+		t.Errorf("expected the received request url to verify, got %v", err)
+	}
+
+	replayed := "https://app.example.com/other?" + parsed.RawQuery
+	if err := VerifyConversionCallbackURL(replayed, jwt); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected a token replayed on another path to be rejected, got %v", err)
+	}
+
+	tampered := parsed.Query()
+	tampered.Set("file", "99")
+	if err := VerifyConversionCallbackURL("https://app.example.com/conversion/done?"+tampered.Encode(), jwt); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected a tampered query to be rejected, got %v", err)
+	}
+
+	extended := parsed.Query()
+	extended.Add("admin", "1")
+	if err := VerifyConversionCallbackURL("https://app.example.com/conversion/done?"+extended.Encode(), jwt); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected an added query parameter to be rejected, got %v", err)
+	}
+
+	if err := VerifyConversionCallbackURL(callbackURL, NewOnlyofficeJWTManager("other")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected a token signed with another secret to be rejected, got %v", err)
+	}
+
+	if err := VerifyConversionCallbackURL("https://app.example.com/conversion/done", jwt); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected a missing token to be rejected, got %v", err)
+	}
+}
+
+func TestConversionCallbackURLExpired(t *testing.T) {
+	jwt := NewOnlyofficeJWTManager("secret")
+	token, err := jwt.Sign(conversionCallbackClaims{Path: "/conversion/done", ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyConversionCallbackURL("https://app.example.com/conversion/done?token="+token, jwt); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("expected ErrTokenExpired, got %v", err)
+	}
+}
+
+func TestBuildConversionCallbackURLInvalid(t *testing.T) {
+	jwt := NewOnlyofficeJWTManager("secret")
+	for _, base := range []string{"", "/relative", "ftp://app.example.com/done", "https://"} {
+		if _, err := BuildConversionCallbackURL(base, jwt, time.Minute); !errors.Is(err, ErrInvalidCallbackURL) {
+			t.Errorf("BuildConversionCallbackURL(%q) = %v; expected ErrInvalidCallbackURL", base, err)
+		}
+	}
+
+	if _, err := BuildConversionCallbackURL("https://app.example.com/done", jwt, 0); !errors.Is(err, ErrInvalidCallbackURL) {
+		t.Errorf("expected a zero ttl to be rejected, got %v", err)
+	}
+
+	if _, err := BuildConversionCallbackURL("http://app.example.com/done", jwt, time.Minute, WithRequireHTTPS(true)); !errors.Is(err, ErrInvalidCallbackURL) {
+		t.Errorf("expected http to be rejected with RequireHTTPS, got %v", err)
+	}
+
+	if _, err := BuildConversionCallbackURL("https://app.example.com/done", jwt, time.Minute, WithRequireHTTPS(true)); err != nil {
+		t.Errorf("expected https to be accepted with RequireHTTPS, got %v", err)
+	}
+}
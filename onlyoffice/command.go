@@ -0,0 +1,219 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	_OnlyofficeCommandServicePath = "/coauthoring/CommandService.ashx"
+	_OnlyofficeHealthCheckPath    = "/healthcheck"
+)
+
+// ServerInfo describes the document server build.
+type ServerInfo struct {
+	Version string `json:"version"`
+}
+
+// Health summarizes the document server state for admin pages.
+type Health struct {
+	Reachable      bool   `json:"reachable"`
+	Healthy        bool   `json:"healthy"`
+	Version        string `json:"version"`
+	Licensed       bool   `json:"licensed"`
+	Trial          bool   `json:"trial"`
+	LicenseEndDate string `json:"licenseEndDate,omitempty"`
+}
+
+type OnlyofficeCommandClient interface {
+	Version(ctx context.Context) (ServerInfo, error)
+	HealthCheck(ctx context.Context) (Health, error)
+}
+
+type commandClient struct {
+	serverURL string
+	options   Options
+}
+
+type commandRequest struct {
+	C     string `json:"c"`
+	Key   string `json:"key,omitempty"`
+	Token string `json:"token,omitempty"`
+}
+
+type commandResponse struct {
+	Error   int    `json:"error"`
+	Version string `json:"version"`
+	License struct {
+		EndDate string `json:"end_date"`
+		Trial   bool   `json:"trial"`
+	} `json:"license"`
+	Server struct {
+		ResultType int `json:"resultType"`
+	} `json:"server"`
+}
+
+// License result types reported by the "license" command that mean the server
+// is licensed. 6 is an expired trial and is deliberately missing.
+var _OnlyofficeLicensedResultTypes = map[int]struct{}{
+	3: {},
+	7: {},
+}
+
+// NormalizeServerURL validates a document server address and strips trailing slashes.
+// With WithRequireHTTPS only https addresses are accepted.
+func NormalizeServerURL(rawURL string, opts ...Option) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidServerURL, err)
+	}
+
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", ErrInvalidServerURL
+	}
+
+	if parsed.Scheme != "https" && newOptions(opts...).RequireHTTPS {
+		return "", fmt.Errorf("%w: https is required", ErrInvalidServerURL)
+	}
+
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+	return strings.TrimRight(parsed.String(), "/"), nil
+}
+
+func NewOnlyofficeCommandClient(serverURL string, opts ...Option) (OnlyofficeCommandClient, error) {
+	normalized, err := NormalizeServerURL(serverURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return commandClient{
+		serverURL: normalized,
+		options:   newOptions(opts...),
+	}, nil
+}
+
+func (c commandClient) command(ctx context.Context, cmd commandRequest) (commandResponse, error) {
+	var result commandResponse
+	if c.options.JWTManager != nil && cmd.Token == "" {
+		token, err := c.options.JWTManager.Sign(cmd)
+		if err != nil {
+			return result, err
+		}
+
+		cmd.Token = token
+	}
+
+	body, err := json.Marshal(cmd)
+	if err != nil {
+		return result, err
+	}
+
+	req, err := c.options.newRequest(ctx, http.MethodPost, c.serverURL+_OnlyofficeCommandServicePath, bytes.NewReader(body))
+	if err != nil {
+		return result, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.options.do(HTTPPurposeConversion, req)
+	if err != nil {
+		return result, fmt.Errorf("%w: %s", ErrServerUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	buf, err := io.ReadAll(io.LimitReader(resp.Body, _ServerResponseMaxSize))
+	if err != nil {
+		return result, err
+	}
+
+	if err := parseServerResponse(buf, resp.StatusCode, &result); err != nil {
+		if errors.Is(err, ErrServerError) {
+			return result, fmt.Errorf("%w: %w", ErrCommandFailed, err)
+		}
+
+		return result, err
+	}
+
+	return result, nil
+}
+
+func (c commandClient) Version(ctx context.Context) (ServerInfo, error) {
+	resp, err := c.command(ctx, commandRequest{C: "version"})
+	if err != nil {
+		return ServerInfo{}, err
+	}
+
+	return ServerInfo{Version: resp.Version}, nil
+}
+
+func (c commandClient) healthy(ctx context.Context) (bool, error) {
+	req, err := c.options.newRequest(ctx, http.MethodGet, c.serverURL+_OnlyofficeHealthCheckPath, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := c.options.do(HTTPPurposeValidation, req)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", ErrServerUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	if err != nil {
+		return false, err
+	}
+
+	return resp.StatusCode == http.StatusOK && strings.TrimSpace(string(body)) == "true", nil
+}
+
+// HealthCheck combines the healthcheck endpoint with the version and license commands.
+// ErrServerUnreachable is returned when the server cannot be contacted at all.
+func (c commandClient) HealthCheck(ctx context.Context) (Health, error) {
+	healthy, err := c.healthy(ctx)
+	if err != nil {
+		return Health{}, err
+	}
+
+	health := Health{Reachable: true, Healthy: healthy}
+	info, err := c.Version(ctx)
+	if err != nil {
+		return health, err
+	}
+	health.Version = info.Version
+
+	license, err := c.command(ctx, commandRequest{C: "license"})
+	if err != nil {
+		return health, err
+	}
+
+	_, health.Licensed = _OnlyofficeLicensedResultTypes[license.Server.ResultType]
+	health.Trial = license.License.Trial
+	health.LicenseEndDate = license.License.EndDate
+
+	return health, nil
+}
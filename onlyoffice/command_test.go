@@ -0,0 +1,173 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func newMockCommandServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return newMockLicenseServer(t, 3)
+}
+
+// newMockLicenseServer reports resultType from the license command.
+func newMockLicenseServer(t *testing.T, resultType int) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc(_OnlyofficeHealthCheckPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("true"))
+	})
+	mux.HandleFunc(_OnlyofficeCommandServicePath, func(w http.ResponseWriter, r *http.Request) {
+		var cmd commandRequest
+		if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+			t.Errorf("could not decode command: %v", err)
+		}
+
+		switch cmd.C {
+		case "version":
+			w.Write([]byte(`{"error":0,"version":"7.3.3.50"}`))
+		case "license":
+			w.Write([]byte(`{"error":0,"license":{"end_date":"2030-01-01T00:00:00.000Z","trial":false},"server":{"resultType":` + strconv.Itoa(resultType) + `,"buildVersion":"7.3.3"}}`))
+		default:
+			w.Write([]byte(`{"error":5}`))
+		}
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestNormalizeServerURL(t *testing.T) {
+	normalized, err := NormalizeServerURL(" https://docs.example.com/ ")
+	if err != nil || normalized != "https://docs.example.com" {
+		t.Errorf("unexpected result %q, %v", normalized, err)
+	}
+
+	for _, invalid := range []string{"", "docs.example.com", "ftp://docs.example.com"} {
+		if _, err := NormalizeServerURL(invalid); !errors.Is(err, ErrInvalidServerURL) {
+			t.Errorf("expected ErrInvalidServerURL for %q, got %v", invalid, err)
+		}
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	server := newMockCommandServer(t)
+	defer server.Close()
+
+	client, err := NewOnlyofficeCommandClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	health, err := client.HealthCheck(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := Health{
+		Reachable:      true,
+		Healthy:        true,
+		Version:        "7.3.3.50",
+		Licensed:       true,
+		LicenseEndDate: "2030-01-01T00:00:00.000Z",
+	}
+	if health != expected {
+		t.Errorf("expected %+v, got %+v", expected, health)
+	}
+}
+
+func TestHealthCheckUnlicensed(t *testing.T) {
+	for resultType, licensed := range map[int]bool{2: false, 3: true, 6: false, 7: true} {
+		server := newMockLicenseServer(t, resultType)
+		client, err := NewOnlyofficeCommandClient(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		health, err := client.HealthCheck(context.Background())
+		server.Close()
+		if err != nil || health.Licensed != licensed {
+			t.Errorf("resultType %d: expected licensed %v, got %+v, %v", resultType, licensed, health, err)
+		}
+	}
+}
+
+func TestHealthCheckUnreachable(t *testing.T) {
+	server := newMockCommandServer(t)
+	server.Close()
+
+	client, err := NewOnlyofficeCommandClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	health, err := client.HealthCheck(context.Background())
+	if !errors.Is(err, ErrServerUnreachable) {
+		t.Errorf("expected ErrServerUnreachable, got %v", err)
+	}
+
+	if health.Reachable {
+		t.Error("expected the server to be reported as unreachable")
+	}
+}
+
+func TestCommandServerError(t *testing.T) {
+	server := newMockCommandServer(t)
+	defer server.Close()
+
+	client, err := NewOnlyofficeCommandClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.(commandClient).command(context.Background(), commandRequest{C: "unknown"})
+
+	var serverErr *ServerError
+	if !errors.Is(err, ErrCommandFailed) || !errors.As(err, &serverErr) || serverErr.Code != 5 {
+		t.Errorf("expected ErrCommandFailed with code 5, got %v", err)
+	}
+}
+
+func TestNormalizeServerURLRequireHTTPS(t *testing.T) {
+	if _, err := NormalizeServerURL("http://docs.example.com"); err != nil {
+		t.Errorf("expected http to be accepted by default, got %v", err)
+	}
+
+	if _, err := NormalizeServerURL("http://docs.example.com", WithRequireHTTPS(true)); !errors.Is(err, ErrInvalidServerURL) {
+		t.Errorf("expected ErrInvalidServerURL, got %v", err)
+	}
+
+	if _, err := NormalizeServerURL("https://docs.example.com", WithRequireHTTPS(true)); err != nil {
+		t.Errorf("expected https to be accepted, got %v", err)
+	}
+
+	if _, err := NewOnlyofficeCommandClient("http://docs.example.com", WithRequireHTTPS(true)); !errors.Is(err, ErrInvalidServerURL) {
+		t.Errorf("expected the command client to reject http, got %v", err)
+	}
+
+	if _, err := NewOnlyofficeConverter("http://docs.example.com", WithRequireHTTPS(true)); !errors.Is(err, ErrInvalidServerURL) {
+		t.Errorf("expected the converter to reject http, got %v", err)
+	}
+}
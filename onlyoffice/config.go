@@ -0,0 +1,481 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	OnlyofficeEditMode string = "edit"
+	OnlyofficeViewMode string = "view"
+)
+
+const (
+	_ViewportFullSize = "100%"
+	// _ViewportWidescreenHeight keeps a 16:9 ratio with a full width viewport.
+	_ViewportWidescreenHeight = "56.25vw"
+)
+
+const (
+	OnlyofficeDesktopType  string = "desktop"
+	OnlyofficeMobileType   string = "mobile"
+	OnlyofficeEmbeddedType string = "embedded"
+)
+
+// Config is the document server editor configuration passed to DocsAPI.DocEditor.
+type Config struct {
+	Document     Document     `json:"document"`
+	DocumentType string       `json:"documentType"`
+	EditorConfig EditorConfig `json:"editorConfig"`
+	Type         string       `json:"type,omitempty"`
+	Width        string       `json:"width,omitempty"`
+	Height       string       `json:"height,omitempty"`
+	// ExpiresAt is the exp claim of signed share configs, in Unix seconds.
+	ExpiresAt int64  `json:"exp,omitempty"`
+	Token     string `json:"token,omitempty"`
+}
+
+type Document struct {
+	FileType    string      `json:"fileType"`
+	Key         string      `json:"key"`
+	Title       string      `json:"title"`
+	URL         string      `json:"url"`
+	Permissions Permissions `json:"permissions"`
+	// ReferenceData identifies the file for external data links between spreadsheets.
+	ReferenceData *ReferenceData `json:"referenceData,omitempty"`
+}
+
+// ReferenceData is the document.referenceData block: FileKey identifies the file
+// within the integration identified by InstanceID, Key optionally carries the
+// document key.
+type ReferenceData struct {
+	FileKey    string `json:"fileKey"`
+	InstanceID string `json:"instanceId"`
+	Key        string `json:"key,omitempty"`
+}
+
+type Permissions struct {
+	Comment   bool `json:"comment"`
+	Copy      bool `json:"copy"`
+	Download  bool `json:"download"`
+	Edit      bool `json:"edit"`
+	FillForms bool `json:"fillForms"`
+	Print     bool `json:"print"`
+	Review    bool `json:"review"`
+}
+
+type EditorConfig struct {
+	CallbackURL   string          `json:"callbackUrl,omitempty"`
+	Lang          string          `json:"lang,omitempty"`
+	Mode          string          `json:"mode,omitempty"`
+	Recent        []RecentEntry   `json:"recent,omitempty"`
+	Templates     []TemplateEntry `json:"templates,omitempty"`
+	User          User            `json:"user"`
+	Customization Customization   `json:"customization"`
+}
+
+// RecentEntry is an item of the editor "Open Recent" menu.
+type RecentEntry struct {
+	Folder string `json:"folder,omitempty"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+}
+
+// TemplateEntry is an item of the editor "Create New" template list.
+type TemplateEntry struct {
+	Image string `json:"image,omitempty"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+type User struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Customization flags are pointers so that unset values are left to the document server defaults.
+type Customization struct {
+	Autosave           *bool `json:"autosave,omitempty"`
+	Chat               *bool `json:"chat,omitempty"`
+	Comments           *bool `json:"comments,omitempty"`
+	Compact            *bool `json:"compactHeader,omitempty"`
+	CompatibleFeatures *bool `json:"compatibleFeatures,omitempty"`
+	Forcesave          *bool `json:"forcesave,omitempty"`
+	Help               *bool `json:"help,omitempty"`
+	HideRightMenu      *bool `json:"hideRightMenu,omitempty"`
+	// RTL switches the editor interface to the right-to-left layout.
+	RTL           *bool   `json:"rtl,omitempty"`
+	Spellcheck    *bool   `json:"spellcheck,omitempty"`
+	ToolbarNoTabs *bool   `json:"toolbarNoTabs,omitempty"`
+	Goback        *GoBack `json:"goback,omitempty"`
+	Logo          *Logo   `json:"logo,omitempty"`
+}
+
+type GoBack struct {
+	Blank *bool  `json:"blank,omitempty"`
+	Text  string `json:"text,omitempty"`
+	URL   string `json:"url,omitempty"`
+}
+
+// Logo replaces the editor header logo. The images are shown in the light and
+// dark themes, and URL is opened when the logo is clicked.
+type Logo struct {
+	Image     string `json:"image,omitempty"`
+	ImageDark string `json:"imageDark,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// Checksum returns a stable hash of the semantically significant config fields.
+// Volatile fields such as the token are excluded, so the checksum only changes
+// when a cached config has to be invalidated.
+func (c *Config) Checksum() string {
+	stable := *c
+	stable.ExpiresAt = 0
+	stable.Token = ""
+
+	buf, err := json.Marshal(stable)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+// ConfigOption customizes a config built by BuildConfig.
+type ConfigOption func(*configBuilder) error
+
+type configBuilder struct {
+	config *Config
+	util   OnlyofficeFileUtility
+	// denyViewOnlyDownload withholds download and print from view-only extensions.
+	denyViewOnlyDownload bool
+}
+
+// WithFileUtility classifies the file with a configured utility instead of the default one.
+func WithFileUtility(util OnlyofficeFileUtility) ConfigOption {
+	return func(b *configBuilder) error {
+		if util != nil {
+			b.util = util
+		}
+
+		return nil
+	}
+}
+
+// WithViewOnlyDownload controls whether view-only extensions (pdf, djvu, ...)
+// get the download and print permissions. They are granted by default.
+func WithViewOnlyDownload(allow bool) ConfigOption {
+	return func(b *configBuilder) error {
+		b.denyViewOnlyDownload = !allow
+		return nil
+	}
+}
+
+// WithUser sets the editing user. The display name is cleaned up with NormalizeUserName.
+func WithUser(id, name string) ConfigOption {
+	return func(b *configBuilder) error {
+		b.config.EditorConfig.User = User{ID: id, Name: NormalizeUserName(name)}
+		return nil
+	}
+}
+
+// WithLang sets the editor language, normalized with NormalizeLocale. Right-to-left
+// locales also enable the RTL interface unless customization.rtl is already set.
+func WithLang(lang string) ConfigOption {
+	return func(b *configBuilder) error {
+		normalized, err := NormalizeLocale(lang)
+		if err != nil {
+			return err
+		}
+
+		b.config.EditorConfig.Lang = normalized
+		if IsRTLLocale(normalized) && b.config.EditorConfig.Customization.RTL == nil {
+			enabled := true
+			b.config.EditorConfig.Customization.RTL = &enabled
+		}
+
+		return nil
+	}
+}
+
+// WithCallbackURL sets the url the document server posts callbacks to.
+// With WithRequireHTTPS only https urls are accepted.
+func WithCallbackURL(callbackURL string, opts ...Option) ConfigOption {
+	return func(b *configBuilder) error {
+		if err := validateCallbackScheme(callbackURL, newOptions(opts...).RequireHTTPS); err != nil {
+			return err
+		}
+
+		b.config.EditorConfig.CallbackURL = callbackURL
+		return nil
+	}
+}
+
+// WithRecent sets the recent documents list. Every entry needs a title and an http(s) url.
+func WithRecent(entries ...RecentEntry) ConfigOption {
+	return func(b *configBuilder) error {
+		for _, entry := range entries {
+			if err := validateEditorEntry(entry.Title, entry.URL); err != nil {
+				return err
+			}
+		}
+
+		b.config.EditorConfig.Recent = append([]RecentEntry(nil), entries...)
+		return nil
+	}
+}
+
+// WithTemplates sets the template list. Every entry needs a title and an http(s) url.
+func WithTemplates(entries ...TemplateEntry) ConfigOption {
+	return func(b *configBuilder) error {
+		for _, entry := range entries {
+			if err := validateEditorEntry(entry.Title, entry.URL); err != nil {
+				return err
+			}
+		}
+
+		b.config.EditorConfig.Templates = append([]TemplateEntry(nil), entries...)
+		return nil
+	}
+}
+
+func validateEditorEntry(title, entryURL string) error {
+	if strings.TrimSpace(title) == "" {
+		return fmt.Errorf("%w: missing title", ErrInvalidEditorEntry)
+	}
+
+	parsed, err := url.Parse(entryURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: invalid url %q", ErrInvalidEditorEntry, entryURL)
+	}
+
+	return nil
+}
+
+// WithGoBack sets customization.goback.url. To prevent open redirects the target
+// has to be a relative same-origin path or an http(s) url on one of allowedHosts.
+func WithGoBack(goBackURL string, allowedHosts []string) ConfigOption {
+	return func(b *configBuilder) error {
+		if err := validateGoBackURL(goBackURL, allowedHosts); err != nil {
+			return err
+		}
+
+		if b.config.EditorConfig.Customization.Goback == nil {
+			b.config.EditorConfig.Customization.Goback = &GoBack{}
+		}
+
+		b.config.EditorConfig.Customization.Goback.URL = goBackURL
+		return nil
+	}
+}
+
+func validateGoBackURL(goBackURL string, allowedHosts []string) error {
+	parsed, err := url.Parse(goBackURL)
+	if err != nil || goBackURL == "" {
+		return ErrInvalidGoBackURL
+	}
+
+	if parsed.Scheme == "" && parsed.Host == "" {
+		if strings.HasPrefix(goBackURL, "/") && !strings.HasPrefix(goBackURL, "//") && !strings.HasPrefix(goBackURL, "/\\") {
+			return nil
+		}
+
+		return ErrInvalidGoBackURL
+	}
+
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || !isHostAllowed(parsed.Hostname(), allowedHosts) {
+		return ErrInvalidGoBackURL
+	}
+
+	return nil
+}
+
+// WithLogo sets customization.logo. Every url has to be on one of allowedHosts.
+// Images must use https to avoid mixed content, the link may use http or https.
+func WithLogo(logo Logo, allowedHosts []string) ConfigOption {
+	return func(b *configBuilder) error {
+		for _, image := range []string{logo.Image, logo.ImageDark} {
+			if err := validateLogoURL(image, allowedHosts, "https"); err != nil {
+				return err
+			}
+		}
+
+		if err := validateLogoURL(logo.URL, allowedHosts, "http", "https"); err != nil {
+			return err
+		}
+
+		b.config.EditorConfig.Customization.Logo = &logo
+		return nil
+	}
+}
+
+// WithReferenceData sets document.referenceData. FileKey and InstanceID are required.
+func WithReferenceData(ref ReferenceData) ConfigOption {
+	return func(b *configBuilder) error {
+		if strings.TrimSpace(ref.FileKey) == "" {
+			return fmt.Errorf("%w: missing fileKey", ErrInvalidReferenceData)
+		}
+
+		if strings.TrimSpace(ref.InstanceID) == "" {
+			return fmt.Errorf("%w: missing instanceId", ErrInvalidReferenceData)
+		}
+
+		b.config.Document.ReferenceData = &ref
+		return nil
+	}
+}
+
+func validateLogoURL(logoURL string, allowedHosts []string, schemes ...string) error {
+	if logoURL == "" {
+		return nil
+	}
+
+	parsed, err := url.Parse(logoURL)
+	if err != nil || !slices.Contains(schemes, parsed.Scheme) || !isHostAllowed(parsed.Hostname(), allowedHosts) {
+		return fmt.Errorf("%w: %q", ErrInvalidLogoURL, logoURL)
+	}
+
+	return nil
+}
+
+// BuildConfig creates an editor config for a file. The title is normalized with
+// NormalizeTitle. The mode and permissions follow the extension capability:
+// editable and loss-editable files are opened for editing, everything else for viewing.
+func BuildConfig(title, fileURL, key, ext string, opts ...ConfigOption) (*Config, error) {
+	title, err := NormalizeTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := configBuilder{
+		config: &Config{
+			Document: Document{
+				Key:   key,
+				Title: title,
+				URL:   fileURL,
+			},
+			Type: OnlyofficeDesktopType,
+		},
+	}
+
+	for _, opt := range opts {
+		if err := opt(&builder); err != nil {
+			return nil, err
+		}
+	}
+
+	if builder.util == nil {
+		builder.util = defaultFileUtility()
+	}
+
+	fileType := normalizeExtension(ext)
+	docType, err := builder.util.GetFileType(fileType)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, perms, err := resolveAccess(builder.util, fileType, RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	downloadable := !builder.denyViewOnlyDownload || !builder.util.IsExtensionViewOnly(fileType)
+	perms.Download = downloadable
+	perms.Print = downloadable
+
+	config := builder.config
+	config.Document.FileType = fileType
+	config.DocumentType = docType
+	config.EditorConfig.Mode = mode
+	config.Document.Permissions = perms
+
+	return config, nil
+}
+
+// RecommendedViewport returns CSS width and height hints for an embedded editor.
+// Presentations keep a 16:9 ratio, documents and spreadsheets use the full height.
+func RecommendedViewport(docType string) (width, height string) {
+	if normalized, err := NormalizeDocumentType(docType); err == nil && normalized == OnlyofficeSlideType {
+		return _ViewportFullSize, _ViewportWidescreenHeight
+	}
+
+	return _ViewportFullSize, _ViewportFullSize
+}
+
+// BuildPreviewConfig creates a stripped-down config for preview and thumbnail flows:
+// the document is opened in the embedded view mode without a callback, chat or
+// comments, regardless of whether the extension is editable. The size follows
+// RecommendedViewport.
+func BuildPreviewConfig(title, fileURL, key, ext string, opts ...ConfigOption) (*Config, error) {
+	config, err := BuildConfig(title, fileURL, key, ext, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	disabled := false
+	config.Type = OnlyofficeEmbeddedType
+	config.Width, config.Height = RecommendedViewport(config.DocumentType)
+	config.EditorConfig.Mode = OnlyofficeViewMode
+	config.EditorConfig.CallbackURL = ""
+	config.EditorConfig.Customization.Chat = &disabled
+	config.EditorConfig.Customization.Comments = &disabled
+	config.Document.Permissions = Permissions{
+		Copy:     true,
+		Download: config.Document.Permissions.Download,
+		Print:    config.Document.Permissions.Print,
+	}
+
+	return config, nil
+}
+
+// BuildShareConfig creates a view-only config for shared links. The config is
+// signed with jwt and the token carries expiry as its exp claim, so Verify
+// rejects the link with ErrTokenExpired once it is stale.
+func BuildShareConfig(title, fileURL, key, ext string, jwt OnlyofficeJWTManager, expiry time.Time, opts ...ConfigOption) (*Config, error) {
+	config, err := BuildConfig(title, fileURL, key, ext, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	config.EditorConfig.Mode = OnlyofficeViewMode
+	config.EditorConfig.CallbackURL = ""
+	config.Document.Permissions = Permissions{
+		Copy:     true,
+		Download: config.Document.Permissions.Download,
+		Print:    config.Document.Permissions.Print,
+	}
+	config.ExpiresAt = expiry.Unix()
+
+	token, err := jwt.Sign(config)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Token = token
+	return config, nil
+}
@@ -0,0 +1,486 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestConfig() *Config {
+	return &Config{
+		Document: Document{
+			FileType: "docx",
+			Key:      "key",
+			Title:    "Report.docx",
+			URL:      "https://storage.example.com/report.docx",
+			Permissions: Permissions{
+				Edit:     true,
+				Download: true,
+			},
+		},
+		DocumentType: OnlyofficeWordType,
+		EditorConfig: EditorConfig{
+			CallbackURL: "https://integration.example.com/callback",
+			Mode:        OnlyofficeEditMode,
+			User:        User{ID: "1", Name: "John"},
+		},
+	}
+}
+
+func TestConfigChecksum(t *testing.T) {
+	first, second := newTestConfig(), newTestConfig()
+	first.Token = "first"
+	second.Token = "second"
+
+	if first.Checksum() == "" || first.Checksum() != second.Checksum() {
+		t.Error("expected configs differing only in token to share a checksum")
+	}
+
+	second.Document.Permissions.Edit = false
+	if first.Checksum() == second.Checksum() {
+		t.Error("expected configs differing in permissions to have different checksums")
+	}
+
+	third := newTestConfig()
+	third.EditorConfig.CallbackURL = "https://integration.example.com/other"
+	if first.Checksum() == third.Checksum() {
+		t.Error("expected configs differing in callback url to have different checksums")
+	}
+}
+
+func TestBuildConfig(t *testing.T) {
+	config, err := BuildConfig("Report.docx", "https://storage.example.com/report.docx", "key", "DOCX",
+		WithUser("1", "John"), WithCallbackURL("https://integration.example.com/callback"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.DocumentType != OnlyofficeWordType || config.Document.FileType != "docx" || config.EditorConfig.Mode != OnlyofficeEditMode {
+		t.Errorf("unexpected config %+v", config)
+	}
+
+	if !config.Document.Permissions.Edit || config.EditorConfig.User.Name != "John" {
+		t.Errorf("unexpected config %+v", config)
+	}
+
+	config, err = BuildConfig("Scan.pdf", "https://storage.example.com/scan.pdf", "key", "pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.EditorConfig.Mode != OnlyofficeViewMode || config.Document.Permissions.Edit {
+		t.Errorf("expected a view-only config, got %+v", config)
+	}
+
+	if _, err := BuildConfig("Setup.exe", "https://storage.example.com/setup.exe", "key", "exe"); !errors.Is(err, ErrOnlyofficeExtensionNotSupported) {
+		t.Errorf("expected ErrOnlyofficeExtensionNotSupported, got %v", err)
+	}
+}
+
+func TestWithGoBack(t *testing.T) {
+	allowedHosts := []string{"app.example.com"}
+	for _, allowed := range []string{"https://app.example.com/files", "/files?folder=1"} {
+		config, err := BuildConfig("Report.docx", "https://storage.example.com/report.docx", "key", "docx", WithGoBack(allowed, allowedHosts))
+		if err != nil {
+			t.Errorf("expected %q to be allowed, got %v", allowed, err)
+			continue
+		}
+
+		if config.EditorConfig.Customization.Goback == nil || config.EditorConfig.Customization.Goback.URL != allowed {
+			t.Errorf("expected goback url %q, got %+v", allowed, config.EditorConfig.Customization.Goback)
+		}
+	}
+
+	for _, disallowed := range []string{"https://evil.example.org/phish", "//evil.example.org", "javascript:alert(1)", ""} {
+		if _, err := BuildConfig("Report.docx", "https://storage.example.com/report.docx", "key", "docx", WithGoBack(disallowed, allowedHosts)); !errors.Is(err, ErrInvalidGoBackURL) {
+			t.Errorf("expected %q to be rejected, got %v", disallowed, err)
+		}
+	}
+}
+
+func TestWithCallbackURL(t *testing.T) {
+	config, err := BuildConfig("Report.docx", "https://storage.example.com/report.docx", "key", "docx",
+		WithCallbackURL("https://integration.example.com/callback", WithRequireHTTPS(true)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.EditorConfig.CallbackURL != "https://integration.example.com/callback" {
+		t.Errorf("expected the callback url to be set, got %q", config.EditorConfig.CallbackURL)
+	}
+
+	if _, err := BuildConfig("Report.docx", "https://storage.example.com/report.docx", "key", "docx",
+		WithCallbackURL("http://integration.example.com/callback")); err != nil {
+		t.Errorf("expected http to be allowed by default, got %v", err)
+	}
+
+	for _, rejected := range []string{"http://integration.example.com/callback", "/callback", ""} {
+		if _, err := BuildConfig("Report.docx", "https://storage.example.com/report.docx", "key", "docx",
+			WithCallbackURL(rejected, WithRequireHTTPS(true))); !errors.Is(err, ErrInvalidCallbackURL) {
+			t.Errorf("expected %q to be rejected, got %v", rejected, err)
+		}
+	}
+}
+
+func TestCustomizationFlags(t *testing.T) {
+	enabled, disabled := true, false
+	customization := Customization{
+		Spellcheck:         &disabled,
+		CompatibleFeatures: &enabled,
+		HideRightMenu:      &enabled,
+	}
+
+	buf, err := json.Marshal(customization)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"spellcheck":         false,
+		"compatibleFeatures": true,
+		"hideRightMenu":      true,
+	}
+
+	if len(decoded) != len(expected) {
+		t.Errorf("expected only set flags to be marshalled, got %s", buf)
+	}
+
+	for key, value := range expected {
+		if decoded[key] != value {
+			t.Errorf("expected %s to be %v, got %v", key, value, decoded[key])
+		}
+	}
+
+	if _, ok := decoded["toolbarNoTabs"]; ok {
+		t.Error("expected unset toolbarNoTabs to be omitted")
+	}
+}
+
+func TestBuildPreviewConfig(t *testing.T) {
+	config, err := BuildPreviewConfig("Report.docx", "https://storage.example.com/report.docx", "key", "docx",
+		WithCallbackURL("https://integration.example.com/callback"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.EditorConfig.CallbackURL != "" {
+		t.Errorf("expected no callback, got %q", config.EditorConfig.CallbackURL)
+	}
+
+	if config.EditorConfig.Mode != OnlyofficeViewMode || config.Type != OnlyofficeEmbeddedType {
+		t.Errorf("expected an embedded view config, got %+v", config)
+	}
+
+	permissions := config.Document.Permissions
+	if permissions.Edit || permissions.Comment || permissions.Review || permissions.FillForms {
+		t.Errorf("expected read-only permissions, got %+v", permissions)
+	}
+
+	if chat := config.EditorConfig.Customization.Chat; chat == nil || *chat {
+		t.Error("expected chat to be disabled")
+	}
+
+	buf, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(buf, []byte("callbackUrl")) {
+		t.Errorf("expected callbackUrl to be omitted, got %s", buf)
+	}
+}
+
+func TestWithViewOnlyDownload(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []ConfigOption
+		ext      string
+		download bool
+	}{
+		{"pdf by default", nil, "pdf", true},
+		{"pdf allowed", []ConfigOption{WithViewOnlyDownload(true)}, "pdf", true},
+		{"pdf denied", []ConfigOption{WithViewOnlyDownload(false)}, "pdf", false},
+		{"docx is not view-only", []ConfigOption{WithViewOnlyDownload(false)}, "docx", true},
+	}
+
+	for _, test := range tests {
+		config, err := BuildConfig("File."+test.ext, "https://storage.example.com/file", "key", test.ext, test.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		preview, err := BuildPreviewConfig("File."+test.ext, "https://storage.example.com/file", "key", test.ext, test.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, permissions := range []Permissions{config.Document.Permissions, preview.Document.Permissions} {
+			if permissions.Download != test.download || permissions.Print != test.download {
+				t.Errorf("%s: expected download and print to be %v, got %+v", test.name, test.download, permissions)
+			}
+		}
+	}
+}
+
+func TestWithRecentAndTemplates(t *testing.T) {
+	config, err := BuildConfig("Report.docx", "https://storage.example.com/report.docx", "key", "docx",
+		WithRecent(RecentEntry{Folder: "Reports", Title: "Q1.docx", URL: "https://app.example.com/files/1"}),
+		WithTemplates(TemplateEntry{Image: "https://app.example.com/blank.png", Title: "Blank", URL: "https://app.example.com/new?template=blank"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := json.Marshal(config.EditorConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		Recent    []map[string]string `json:"recent"`
+		Templates []map[string]string `json:"templates"`
+	}
+	if err := json.Unmarshal(buf, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedRecent := map[string]string{"folder": "Reports", "title": "Q1.docx", "url": "https://app.example.com/files/1"}
+	if len(decoded.Recent) != 1 || !reflect.DeepEqual(decoded.Recent[0], expectedRecent) {
+		t.Errorf("unexpected recent section %s", buf)
+	}
+
+	expectedTemplate := map[string]string{"image": "https://app.example.com/blank.png", "title": "Blank", "url": "https://app.example.com/new?template=blank"}
+	if len(decoded.Templates) != 1 || !reflect.DeepEqual(decoded.Templates[0], expectedTemplate) {
+		t.Errorf("unexpected templates section %s", buf)
+	}
+
+	if _, err := BuildConfig("Report.docx", "https://storage.example.com/report.docx", "key", "docx",
+		WithRecent(RecentEntry{Title: "Q1.docx"})); !errors.Is(err, ErrInvalidEditorEntry) {
+		t.Errorf("expected a recent entry without url to be rejected, got %v", err)
+	}
+
+	if _, err := BuildConfig("Report.docx", "https://storage.example.com/report.docx", "key", "docx",
+		WithTemplates(TemplateEntry{URL: "https://app.example.com/new"})); !errors.Is(err, ErrInvalidEditorEntry) {
+		t.Errorf("expected a template entry without title to be rejected, got %v", err)
+	}
+}
+
+func TestBuildConfigNormalizesTitle(t *testing.T) {
+	config, err := BuildConfig("  Quarterly\n\x00Report.docx ", "https://storage.example.com/report.docx", "key", "docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Document.Title != "Quarterly Report.docx" {
+		t.Errorf("expected a normalized title, got %q", config.Document.Title)
+	}
+
+	if _, err := BuildConfig(" \t ", "https://storage.example.com/report.docx", "key", "docx"); !errors.Is(err, ErrInvalidTitle) {
+		t.Errorf("expected ErrInvalidTitle, got %v", err)
+	}
+}
+
+func TestWithLang(t *testing.T) {
+	for lang, rtl := range map[string]bool{"ar-SA": true, "he": true, "en-US": false} {
+		config, err := BuildConfig("Report.docx", "https://storage.example.com/report.docx", "key", "docx", WithLang(lang))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if config.EditorConfig.Lang != lang {
+			t.Errorf("expected lang %q, got %q", lang, config.EditorConfig.Lang)
+		}
+
+		flag := config.EditorConfig.Customization.RTL
+		if rtl && (flag == nil || !*flag) {
+			t.Errorf("expected the RTL layout for %q", lang)
+		}
+
+		if !rtl && flag != nil {
+			t.Errorf("expected the RTL flag to be unset for %q", lang)
+		}
+	}
+}
+
+func TestBuildShareConfig(t *testing.T) {
+	manager := NewOnlyofficeJWTManager("secret")
+	expiry := time.Now().Add(time.Hour)
+	config, err := BuildShareConfig("report.docx", "https://storage.example.com/report.docx", "key", "docx", manager, expiry,
+		WithCallbackURL("https://integration.example.com/callback"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.EditorConfig.Mode != OnlyofficeViewMode || config.EditorConfig.CallbackURL != "" {
+		t.Errorf("expected a view-only config without callback, got %+v", config.EditorConfig)
+	}
+
+	if config.Document.Permissions.Edit || config.Document.Permissions.Comment || config.Document.Permissions.Review {
+		t.Errorf("expected read-only permissions, got %+v", config.Document.Permissions)
+	}
+
+	var claims Config
+	if err := manager.Verify(config.Token, &claims); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if claims.ExpiresAt != expiry.Unix() || claims.Document.Key != "key" {
+		t.Errorf("expected the token to carry exp %d, got %d", expiry.Unix(), claims.ExpiresAt)
+	}
+
+	expired, err := BuildShareConfig("report.docx", "https://storage.example.com/report.docx", "key", "docx", manager, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := manager.Verify(expired.Token, nil); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("expected ErrTokenExpired, got %v", err)
+	}
+
+	if expired.Checksum() != config.Checksum() {
+		t.Error("expected configs differing only in expiry to share a checksum")
+	}
+}
+
+func TestWithLogo(t *testing.T) {
+	allowedHosts := []string{"cdn.example.com", "app.example.com"}
+	logo := Logo{
+		Image:     "https://cdn.example.com/logo.png",
+		ImageDark: "https://cdn.example.com/logo-dark.png",
+		URL:       "http://app.example.com",
+	}
+
+	config, err := BuildConfig("Report.docx", "https://storage.example.com/report.docx", "key", "docx", WithLogo(logo, allowedHosts))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.EditorConfig.Customization.Logo == nil || *config.EditorConfig.Customization.Logo != logo {
+		t.Errorf("expected logo %+v, got %+v", logo, config.EditorConfig.Customization.Logo)
+	}
+
+	for _, invalid := range []Logo{
+		{Image: "http://cdn.example.com/logo.png"},
+		{ImageDark: "https://evil.example.org/logo.png"},
+		{Image: "data:image/png;base64,AAAA"},
+		{URL: "javascript:alert(1)"},
+		{URL: "https://evil.example.org"},
+		{URL: "/relative"},
+	} {
+		if _, err := BuildConfig("Report.docx", "https://storage.example.com/report.docx", "key", "docx", WithLogo(invalid, allowedHosts)); !errors.Is(err, ErrInvalidLogoURL) {
+			t.Errorf("expected %+v to be rejected, got %v", invalid, err)
+		}
+	}
+}
+
+func TestWithReferenceData(t *testing.T) {
+	ref := ReferenceData{FileKey: "file-42", InstanceID: "https://app.example.com"}
+	config, err := BuildConfig("Data.xlsx", "https://storage.example.com/data.xlsx", "key", "xlsx", WithReferenceData(ref))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := json.Marshal(config.Document.ReferenceData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := `{"fileKey":"file-42","instanceId":"https://app.example.com"}`; string(encoded) != expected {
+		t.Errorf("referenceData = %s; expected %s", encoded, expected)
+	}
+
+	if err := config.ValidateSchema(); err != nil {
+		t.Errorf("expected the config to match the schema, got %v", err)
+	}
+
+	for _, invalid := range []ReferenceData{
+		{InstanceID: "https://app.example.com"},
+		{FileKey: "  ", InstanceID: "https://app.example.com"},
+		{FileKey: "file-42"},
+	} {
+		if _, err := BuildConfig("Data.xlsx", "https://storage.example.com/data.xlsx", "key", "xlsx", WithReferenceData(invalid)); !errors.Is(err, ErrInvalidReferenceData) {
+			t.Errorf("expected %+v to be rejected, got %v", invalid, err)
+		}
+	}
+}
+
+func TestBuildConfigFillForms(t *testing.T) {
+	fill := Permissions{Copy: true, Download: true, FillForms: true, Print: true}
+	tests := []struct {
+		ext      string
+		pdfForms bool
+		mode     string
+		fill     bool
+	}{
+		{"oform", false, OnlyofficeEditMode, true},
+		{"pdf", false, OnlyofficeViewMode, false},
+		{"pdf", true, OnlyofficeEditMode, true},
+	}
+
+	for _, test := range tests {
+		util := NewOnlyofficeFileUtility(WithPDFForms(test.pdfForms))
+		config, err := BuildConfig("Form."+test.ext, "https://storage.example.com/form", "key", test.ext, WithFileUtility(util))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if config.EditorConfig.Mode != test.mode || (config.Document.Permissions == fill) != test.fill {
+			t.Errorf("BuildConfig(%q) with pdf forms %v = %q, %+v; expected mode %q and fill %v",
+				test.ext, test.pdfForms, config.EditorConfig.Mode, config.Document.Permissions, test.mode, test.fill)
+		}
+	}
+}
+
+func TestRecommendedViewport(t *testing.T) {
+	tests := []struct {
+		docType string
+		width   string
+		height  string
+	}{
+		{OnlyofficeWordType, "100%", "100%"},
+		{OnlyofficeCellType, "100%", "100%"},
+		{OnlyofficeSlideType, "100%", "56.25vw"},
+		{"Presentation", "100%", "56.25vw"},
+		{"unknown", "100%", "100%"},
+	}
+
+	for _, test := range tests {
+		if width, height := RecommendedViewport(test.docType); width != test.width || height != test.height {
+			t.Errorf("RecommendedViewport(%q) = %q, %q; expected %q, %q", test.docType, width, height, test.width, test.height)
+		}
+	}
+
+	config, err := BuildPreviewConfig("Slides.pptx", "https://storage.example.com/slides.pptx", "key", "pptx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Width != "100%" || config.Height != "56.25vw" {
+		t.Errorf("expected the preview config to use the slide viewport, got %q, %q", config.Width, config.Height)
+	}
+}
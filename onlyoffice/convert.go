@@ -0,0 +1,583 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	_OnlyofficeConvertServicePath = "/ConvertService.ashx"
+	_DefaultConvertPollInterval   = time.Second
+	_ConvertedURLCheckTimeout     = 5 * time.Second
+	_ConversionCancelTimeout      = 5 * time.Second
+	_ConversionBaseTimeout        = 30 * time.Second
+	_ConversionMaxTimeout         = 30 * time.Minute
+)
+
+// _OnlyofficeConversionTimePerMB is the expected conversion time per megabyte
+// of source file. Spreadsheets are the slowest to convert.
+var _OnlyofficeConversionTimePerMB = map[string]time.Duration{
+	OnlyofficeWordType:  2 * time.Second,
+	OnlyofficeCellType:  6 * time.Second,
+	OnlyofficeSlideType: 3 * time.Second,
+}
+
+type Thumbnail struct {
+	Aspect int  `json:"aspect,omitempty"`
+	First  bool `json:"first,omitempty"`
+	Height int  `json:"height,omitempty"`
+	Width  int  `json:"width,omitempty"`
+}
+
+// CSVDelimiter is the conversion service delimiter of csv files. The zero value
+// leaves the delimiter to the server.
+type CSVDelimiter int
+
+const (
+	CSVDelimiterTab       CSVDelimiter = 1
+	CSVDelimiterSemicolon CSVDelimiter = 2
+	CSVDelimiterColon     CSVDelimiter = 3
+	CSVDelimiterComma     CSVDelimiter = 4
+	CSVDelimiterSpace     CSVDelimiter = 5
+)
+
+// _OnlyofficeCSVCodePages lists the code pages the conversion service reads
+// and writes csv files in.
+var _OnlyofficeCSVCodePages = map[int]struct{}{
+	437: {}, 720: {}, 737: {}, 775: {}, 850: {}, 852: {}, 855: {}, 857: {}, 858: {}, 860: {}, 861: {},
+	862: {}, 863: {}, 865: {}, 866: {}, 869: {}, 874: {}, 932: {}, 936: {}, 949: {}, 950: {},
+	1200: {}, 1201: {}, 1250: {}, 1251: {}, 1252: {}, 1253: {}, 1254: {}, 1255: {}, 1256: {}, 1257: {}, 1258: {},
+	10000: {}, 12000: {}, 12001: {}, 20866: {}, 21866: {},
+	28591: {}, 28592: {}, 28593: {}, 28594: {}, 28595: {}, 28596: {}, 28597: {}, 28598: {}, 28599: {}, 28605: {},
+	65000: {}, 65001: {},
+}
+
+// ConvertRequest is the conversion service request body.
+type ConvertRequest struct {
+	Async      bool       `json:"async"`
+	FileType   string     `json:"filetype"`
+	Key        string     `json:"key"`
+	OutputType string     `json:"outputtype"`
+	Region     string     `json:"region,omitempty"`
+	Thumbnail  *Thumbnail `json:"thumbnail,omitempty"`
+	Title      string     `json:"title,omitempty"`
+	URL        string     `json:"url"`
+	Token      string     `json:"token,omitempty"`
+	// CodePage and Delimiter describe csv files and are only accepted for
+	// spreadsheet conversions from or to csv. Zero values are not sent.
+	CodePage  int          `json:"codePage,omitempty"`
+	Delimiter CSVDelimiter `json:"delimiter,omitempty"`
+	// Size is the source file size in bytes, when known. It is not sent and
+	// only sizes the ConvertAndWait deadline, which is not applied when zero.
+	Size int64 `json:"-"`
+}
+
+// _OnlyofficeConversionTargets lists the legal output types per source document type.
+var _OnlyofficeConversionTargets = map[string]map[string]struct{}{
+	OnlyofficeWordType: {
+		"bmp": {}, "docm": {}, "docx": {}, "docxf": {}, "dotm": {}, "dotx": {}, "epub": {}, "fb2": {}, "gif": {},
+		"html": {}, "jpg": {}, "odt": {}, "oform": {}, "ott": {}, "pdf": {}, "pdfa": {}, "png": {}, "rtf": {}, "txt": {},
+	},
+	OnlyofficeCellType: {
+		"bmp": {}, "csv": {}, "gif": {}, "jpg": {}, "ods": {}, "ots": {}, "pdf": {}, "pdfa": {}, "png": {}, "xlsm": {}, "xlsx": {},
+		"xltm": {}, "xltx": {},
+	},
+	OnlyofficeSlideType: {
+		"bmp": {}, "gif": {}, "jpg": {}, "odp": {}, "otp": {}, "pdf": {}, "pdfa": {}, "png": {}, "potm": {}, "potx": {}, "ppsm": {},
+		"ppsx": {}, "pptm": {}, "pptx": {},
+	},
+}
+
+// _OnlyofficeViewOnlyEditableTargets maps view-only extensions to the editable
+// format a copy can be converted to. Scanned formats such as djvu have none.
+var _OnlyofficeViewOnlyEditableTargets = map[string]string{
+	"oxps": "docx",
+	"pdf":  "docx",
+	"xlsb": "xlsx",
+	"xps":  "docx",
+}
+
+var _OnlyofficeImageOutputTypes = map[string]struct{}{
+	"bmp": {}, "gif": {}, "jpg": {}, "png": {},
+}
+
+// Validate checks the request before it is sent: the key, a supported filetype,
+// an outputtype the filetype can be converted to, an http(s) url, the region,
+// the thumbnail settings and the csv code page and delimiter. Every problem is
+// reported in the joined error, each wrapping ErrInvalidConvertRequest and a
+// more specific error if any.
+// With WithAllowedOutputTypes other output types report ErrOutputTypeNotAllowed.
+// The filetype is classified with the extension options in opts, such as
+// WithExtensionSource and WithDocumentTypeOverrides.
+func (r *ConvertRequest) Validate(opts ...Option) error {
+	if len(opts) == 0 {
+		return r.validate(defaultFileUtility(), newOptions())
+	}
+
+	options := newOptions(opts...)
+	return r.validate(newFileUtility(options), options)
+}
+
+func (r *ConvertRequest) validate(util OnlyofficeFileUtility, options Options) error {
+	var errs []error
+	invalid := func(field string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %w", ErrInvalidConvertRequest, field, err))
+			return
+		}
+
+		errs = append(errs, fmt.Errorf("%w: %s", ErrInvalidConvertRequest, field))
+	}
+
+	if err := ValidateDocumentKey(r.Key); err != nil {
+		invalid("key", err)
+	}
+
+	outputType := normalizeExtension(r.OutputType)
+	docType, err := util.GetFileType(r.FileType)
+	if err != nil {
+		invalid("filetype", err)
+	} else if _, ok := _OnlyofficeConversionTargets[docType][outputType]; !ok {
+		invalid("outputtype", ErrOnlyofficeExtensionNotSupported)
+	} else if !options.isOutputTypeAllowed(outputType) {
+		invalid("outputtype", ErrOutputTypeNotAllowed)
+	}
+
+	if parsed, err := url.Parse(r.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		invalid("url", nil)
+	}
+
+	if r.Region != "" {
+		if _, err := NormalizeLocale(r.Region); err != nil {
+			invalid("region", err)
+		}
+	}
+
+	if r.CodePage != 0 || r.Delimiter != 0 {
+		csv := normalizeExtension(r.FileType) == "csv" || outputType == "csv"
+		if !csv || docType != OnlyofficeCellType {
+			invalid("codePage/delimiter", ErrCSVOptionsNotSupported)
+		}
+
+		if _, ok := _OnlyofficeCSVCodePages[r.CodePage]; r.CodePage != 0 && !ok {
+			invalid("codePage", fmt.Errorf("%w: code page %d", ErrInvalidCSVOption, r.CodePage))
+		}
+
+		if r.Delimiter < 0 || r.Delimiter > CSVDelimiterSpace {
+			invalid("delimiter", fmt.Errorf("%w: delimiter %d", ErrInvalidCSVOption, r.Delimiter))
+		}
+	}
+
+	if r.Thumbnail != nil {
+		if _, ok := _OnlyofficeImageOutputTypes[outputType]; !ok || r.Thumbnail.Aspect < 0 || r.Thumbnail.Aspect > 2 ||
+			r.Thumbnail.Width < 0 || r.Thumbnail.Height < 0 {
+			invalid("thumbnail", nil)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// EditorFileTypeAfterConversion returns the extension the editor receives for a
+// file of originalExt: the OOXML target for formats opened via conversion
+// (doc -> docx) and the normalized original extension otherwise. It uses the
+// built-in extension maps, see OnlyofficeFileUtility for configured ones.
+func EditorFileTypeAfterConversion(originalExt string) (string, error) {
+	return defaultFileUtility().EditorFileTypeAfterConversion(originalExt)
+}
+
+func (u fileUtility) EditorFileTypeAfterConversion(originalExt string) (string, error) {
+	info, err := u.Describe(originalExt)
+	if err != nil {
+		return "", err
+	}
+
+	if info.ConversionTarget != "" {
+		return info.ConversionTarget, nil
+	}
+
+	return info.Extension, nil
+}
+
+// EditableTargetForViewOnly returns the outputtype an editable copy of a view-only
+// file is converted to (pdf -> docx). Extensions that are not view-only or have
+// no sensible editable form (djvu) report ErrNoEditableTarget. It uses the
+// built-in extension maps, see OnlyofficeFileUtility for configured ones.
+func EditableTargetForViewOnly(ext string) (string, error) {
+	return defaultFileUtility().EditableTargetForViewOnly(ext)
+}
+
+func (u fileUtility) EditableTargetForViewOnly(ext string) (string, error) {
+	if !u.IsExtensionSupported(ext) {
+		return "", ErrOnlyofficeExtensionNotSupported
+	}
+
+	target, ok := _OnlyofficeViewOnlyEditableTargets[normalizeExtension(ext)]
+	if !ok || !u.IsExtensionViewOnly(ext) {
+		return "", fmt.Errorf("%w: %s", ErrNoEditableTarget, normalizeExtension(ext))
+	}
+
+	return target, nil
+}
+
+// EstimateConversionTimeout returns a deadline for converting a file of size
+// bytes: a fixed base plus a per-megabyte allowance of the document type,
+// capped at 30 minutes. Unknown document types use the word allowance.
+func EstimateConversionTimeout(size int64, docType string) time.Duration {
+	perMB := _OnlyofficeConversionTimePerMB[OnlyofficeWordType]
+	if normalized, err := NormalizeDocumentType(docType); err == nil {
+		perMB = _OnlyofficeConversionTimePerMB[normalized]
+	}
+
+	megabytes := max(size, 0) >> 20
+	if megabytes >= int64((_ConversionMaxTimeout-_ConversionBaseTimeout)/perMB) {
+		return _ConversionMaxTimeout
+	}
+
+	return _ConversionBaseTimeout + time.Duration(megabytes)*perMB
+}
+
+type ConvertResponse struct {
+	EndConvert bool   `json:"endConvert"`
+	Error      int    `json:"error,omitempty"`
+	FileType   string `json:"fileType,omitempty"`
+	FileURL    string `json:"fileUrl,omitempty"`
+	Percent    int    `json:"percent"`
+}
+
+type OnlyofficeConverter interface {
+	Convert(ctx context.Context, req ConvertRequest) (ConvertResponse, error)
+	// ConvertAndWait sends an asynchronous conversion and polls until it ends or
+	// ctx is done. When the request Size is set and ctx has no tighter deadline,
+	// polling stops after EstimateConversionTimeout. An empty request Key is
+	// replaced with a generated single-use key. With WithCancelAbandonedConversions
+	// a conversion using a generated key is cancelled with CancelConversion when
+	// ctx is done; otherwise an abandoned conversion keeps running on the server.
+	ConvertAndWait(ctx context.Context, req ConvertRequest) (ConvertResponse, error)
+	// ConvertReader uploads a local file as multipart/form-data instead of
+	// passing a url, for document servers that accept uploads. The upload is
+	// aborted with ErrInvalidContentLength once it exceeds the size limit of
+	// filetype. The conversion is synchronous and uses a generated key, so with
+	// WithCancelAbandonedConversions it is cancelled when ctx is done.
+	ConvertReader(ctx context.Context, r io.Reader, filetype, outputtype string) (ConvertResponse, error)
+	// CancelConversion is a best-effort cancellation through the command service
+	// drop command. The conversion service has no cancel operation, and drop
+	// disconnects every user editing a document with this key, so only call it
+	// for keys used by a single conversion, never for a document key shared
+	// with an editing session.
+	CancelConversion(ctx context.Context, key string) error
+	// ValidateConvertedURL checks that a conversion result url can still be fetched.
+	// ErrConvertedURLExpired means the file has to be converted again.
+	ValidateConvertedURL(ctx context.Context, url string) error
+}
+
+type converter struct {
+	serverURL    string
+	options      Options
+	util         fileUtility
+	pollInterval time.Duration
+}
+
+func NewOnlyofficeConverter(serverURL string, opts ...Option) (OnlyofficeConverter, error) {
+	normalized, err := NormalizeServerURL(serverURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	options := newOptions(opts...)
+	return converter{
+		serverURL:    normalized,
+		options:      options,
+		util:         newFileUtility(options),
+		pollInterval: _DefaultConvertPollInterval,
+	}, nil
+}
+
+func (c converter) Convert(ctx context.Context, req ConvertRequest) (ConvertResponse, error) {
+	var result ConvertResponse
+	if err := req.validate(c.util, c.options); err != nil {
+		return result, err
+	}
+
+	if req.Region != "" {
+		req.Region, _ = NormalizeLocale(req.Region)
+	}
+
+	if err := c.sign(&req); err != nil {
+		return result, err
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return result, err
+	}
+
+	hreq, err := c.options.newRequest(ctx, http.MethodPost, c.serverURL+_OnlyofficeConvertServicePath, bytes.NewReader(body))
+	if err != nil {
+		return result, err
+	}
+	hreq.Header.Set("Accept", "application/json")
+	hreq.Header.Set("Content-Type", "application/json")
+
+	return c.do(hreq)
+}
+
+// sign sets the request token when a JWT manager is configured and the
+// caller did not sign the request already.
+func (c converter) sign(req *ConvertRequest) error {
+	if c.options.JWTManager == nil || req.Token != "" {
+		return nil
+	}
+
+	token, err := c.options.JWTManager.Sign(req)
+	if err != nil {
+		return err
+	}
+
+	req.Token = token
+	return nil
+}
+
+func (c converter) do(hreq *http.Request) (ConvertResponse, error) {
+	var result ConvertResponse
+	resp, err := c.options.do(HTTPPurposeConversion, hreq)
+	if err != nil {
+		return result, fmt.Errorf("%w: %s", ErrServerUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	buf, err := io.ReadAll(io.LimitReader(resp.Body, _ServerResponseMaxSize))
+	if err != nil {
+		return result, err
+	}
+
+	if err := parseServerResponse(buf, resp.StatusCode, &result); err != nil {
+		if errors.Is(err, ErrServerError) {
+			return result, fmt.Errorf("%w: %w", ErrConversionFailed, err)
+		}
+
+		return result, err
+	}
+
+	return result, nil
+}
+
+func (c converter) ConvertAndWait(ctx context.Context, req ConvertRequest) (ConvertResponse, error) {
+	ctx, cancel := c.waitContext(ctx, req)
+	defer cancel()
+
+	generated := req.Key == ""
+	if generated {
+		key, err := randomDocumentKey()
+		if err != nil {
+			return ConvertResponse{}, err
+		}
+
+		req.Key = key
+	}
+
+	req.Async = true
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		resp, err := c.Convert(ctx, req)
+		if err != nil && ctx.Err() != nil {
+			c.cancelAbandoned(ctx, req.Key, generated)
+			return resp, ctx.Err()
+		}
+
+		if err != nil || resp.EndConvert {
+			return resp, err
+		}
+
+		select {
+		case <-ctx.Done():
+			c.cancelAbandoned(ctx, req.Key, generated)
+			return resp, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitContext bounds ctx by EstimateConversionTimeout when the request size is
+// known and ctx has no tighter deadline. Without a size ctx alone applies.
+func (c converter) waitContext(ctx context.Context, req ConvertRequest) (context.Context, context.CancelFunc) {
+	if req.Size <= 0 {
+		return ctx, func() {}
+	}
+
+	docType, _ := c.util.GetFileType(req.FileType)
+	estimate := EstimateConversionTimeout(req.Size, docType)
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= estimate {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, estimate)
+}
+
+// cancelAbandoned drops a conversion whose caller gave up when the key was
+// generated by the converter and WithCancelAbandonedConversions is set. The
+// cancelled ctx only provides the values, the drop request gets its own deadline.
+func (c converter) cancelAbandoned(ctx context.Context, key string, generated bool) {
+	if !generated || !c.options.CancelAbandonedConversions {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), _ConversionCancelTimeout)
+	defer cancel()
+
+	_ = c.CancelConversion(ctx, key)
+}
+
+func (c converter) CancelConversion(ctx context.Context, key string) error {
+	if err := ValidateDocumentKey(key); err != nil {
+		return err
+	}
+
+	_, err := commandClient{serverURL: c.serverURL, options: c.options}.command(ctx, commandRequest{C: "drop", Key: key})
+	return err
+}
+
+func (c converter) ConvertReader(ctx context.Context, r io.Reader, filetype, outputtype string) (ConvertResponse, error) {
+	var result ConvertResponse
+	docType, err := c.util.GetFileType(filetype)
+	if err != nil {
+		return result, fmt.Errorf("%w: filetype: %w", ErrInvalidConvertRequest, err)
+	}
+
+	if _, ok := _OnlyofficeConversionTargets[docType][normalizeExtension(outputtype)]; !ok {
+		return result, fmt.Errorf("%w: outputtype: %w", ErrInvalidConvertRequest, ErrOnlyofficeExtensionNotSupported)
+	}
+
+	if !c.options.isOutputTypeAllowed(outputtype) {
+		return result, fmt.Errorf("%w: outputtype: %w", ErrInvalidConvertRequest, ErrOutputTypeNotAllowed)
+	}
+
+	limit, err := c.util.sizeLimit(filetype)
+	if err != nil {
+		return result, err
+	}
+
+	key, err := randomDocumentKey()
+	if err != nil {
+		return result, err
+	}
+
+	req := ConvertRequest{
+		FileType:   normalizeExtension(filetype),
+		Key:        key,
+		OutputType: normalizeExtension(outputtype),
+	}
+	if err := c.sign(&req); err != nil {
+		return result, err
+	}
+
+	meta, err := json.Marshal(req)
+	if err != nil {
+		return result, err
+	}
+
+	pr, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+	uploadErr := make(chan error, 1)
+	go func() {
+		err := writeConvertUpload(form, meta, req.FileType, r, limit)
+		uploadErr <- err
+		pw.CloseWithError(err)
+	}()
+
+	hreq, err := c.options.newRequest(ctx, http.MethodPost, c.serverURL+_OnlyofficeConvertServicePath, pr)
+	if err != nil {
+		pr.Close()
+		return result, err
+	}
+	hreq.Header.Set("Accept", "application/json")
+	hreq.Header.Set("Content-Type", form.FormDataContentType())
+
+	result, err = c.do(hreq)
+	pr.Close()
+	if err != nil && ctx.Err() != nil {
+		c.cancelAbandoned(ctx, key, true)
+	}
+	if upload := <-uploadErr; upload != nil && errors.Is(upload, ErrInvalidContentLength) {
+		return ConvertResponse{}, upload
+	}
+
+	return result, err
+}
+
+// writeConvertUpload writes the request json and the file parts of a
+// ConvertReader upload, failing once more than limit bytes were read.
+func writeConvertUpload(form *multipart.Writer, meta []byte, filetype string, r io.Reader, limit int64) error {
+	if err := form.WriteField("request", string(meta)); err != nil {
+		return err
+	}
+
+	part, err := form.CreateFormFile("file", "file."+filetype)
+	if err != nil {
+		return err
+	}
+
+	n, err := io.Copy(part, io.LimitReader(r, limit+1))
+	if err != nil {
+		return err
+	}
+
+	if n > limit {
+		return ErrInvalidContentLength
+	}
+
+	return form.Close()
+}
+
+func (c converter) ValidateConvertedURL(ctx context.Context, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, _ConvertedURLCheckTimeout)
+	defer cancel()
+
+	req, err := c.options.newRequest(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.options.do(HTTPPurposeValidation, req)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrServerUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusForbidden, resp.StatusCode == http.StatusNotFound:
+		return ErrConvertedURLExpired
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("%w: unexpected status %d", ErrConversionFailed, resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,686 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newMockConvertServer(t *testing.T, handler func(body map[string]interface{}) string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != _OnlyofficeConvertServicePath {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("could not decode conversion request: %v", err)
+		}
+
+		w.Write([]byte(handler(body)))
+	}))
+}
+
+func newTestConverter(t *testing.T, serverURL string, opts ...Option) converter {
+	t.Helper()
+	conv, err := NewOnlyofficeConverter(serverURL, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := conv.(converter)
+	c.pollInterval = time.Millisecond
+	return c
+}
+
+func TestConvertRegion(t *testing.T) {
+	var received map[string]interface{}
+	server := newMockConvertServer(t, func(body map[string]interface{}) string {
+		received = body
+		return `{"endConvert":true,"fileType":"xlsx","fileUrl":"https://docs.example.com/output.xlsx","percent":100}`
+	})
+	defer server.Close()
+
+	conv := newTestConverter(t, server.URL)
+	req := ConvertRequest{FileType: "csv", Key: "key", OutputType: "xlsx", URL: "https://storage.example.com/data.csv", Region: "de_de"}
+	if _, err := conv.Convert(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	if received["region"] != "de-DE" {
+		t.Errorf("expected region de-DE, got %v", received["region"])
+	}
+
+	req.Region = ""
+	if _, err := conv.Convert(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := received["region"]; ok {
+		t.Errorf("expected region to be omitted, got %v", received["region"])
+	}
+
+	req.Region = "not a locale"
+	if _, err := conv.Convert(context.Background(), req); err == nil {
+		t.Error("expected an invalid region to be rejected")
+	}
+}
+
+func TestConvertAndWait(t *testing.T) {
+	calls := 0
+	server := newMockConvertServer(t, func(body map[string]interface{}) string {
+		calls++
+		if body["async"] != true {
+			t.Error("expected an asynchronous conversion request")
+		}
+
+		if calls < 3 {
+			return `{"endConvert":false,"percent":50}`
+		}
+
+		return `{"endConvert":true,"fileType":"docx","fileUrl":"https://docs.example.com/output.docx","percent":100}`
+	})
+	defer server.Close()
+
+	conv := newTestConverter(t, server.URL)
+	resp, err := conv.ConvertAndWait(context.Background(), ConvertRequest{FileType: "doc", Key: "key", OutputType: "docx", URL: "https://storage.example.com/file.doc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !resp.EndConvert || resp.FileURL == "" || calls != 3 {
+		t.Errorf("unexpected response %+v after %d calls", resp, calls)
+	}
+}
+
+func TestValidateConvertedURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	conv := newTestConverter(t, server.URL)
+	if err := conv.ValidateConvertedURL(context.Background(), server.URL+"/output.docx"); err != nil {
+		t.Errorf("expected a reachable url, got %v", err)
+	}
+
+	for _, path := range []string{"/forbidden", "/missing"} {
+		if err := conv.ValidateConvertedURL(context.Background(), server.URL+path); !errors.Is(err, ErrConvertedURLExpired) {
+			t.Errorf("expected ErrConvertedURLExpired for %s, got %v", path, err)
+		}
+	}
+}
+
+func TestConvertRequestValidate(t *testing.T) {
+	valid := ConvertRequest{
+		FileType:   "docx",
+		Key:        "key",
+		OutputType: "pdf",
+		URL:        "https://storage.example.com/report.docx",
+		Region:     "en-US",
+	}
+
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("expected a valid request, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*ConvertRequest)
+		err    error
+	}{
+		{"key", func(r *ConvertRequest) { r.Key = "invalid key" }, ErrInvalidDocumentKey},
+		{"filetype", func(r *ConvertRequest) { r.FileType = "exe" }, ErrOnlyofficeExtensionNotSupported},
+		{"outputtype", func(r *ConvertRequest) { r.OutputType = "xlsx" }, ErrOnlyofficeExtensionNotSupported},
+		{"url", func(r *ConvertRequest) { r.URL = "file:///etc/passwd" }, ErrInvalidConvertRequest},
+		{"region", func(r *ConvertRequest) { r.Region = "not a locale" }, ErrInvalidLocale},
+		{"thumbnail", func(r *ConvertRequest) { r.Thumbnail = &Thumbnail{Aspect: 1} }, ErrInvalidConvertRequest},
+	}
+
+	for _, test := range tests {
+		req := valid
+		test.modify(&req)
+		err := req.Validate()
+		if !errors.Is(err, ErrInvalidConvertRequest) || !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	req := valid
+	req.Key = ""
+	req.URL = ""
+	err := req.Validate()
+	if !errors.Is(err, ErrInvalidDocumentKey) || !strings.Contains(err.Error(), "url") {
+		t.Errorf("expected every invalid field to be reported, got %v", err)
+	}
+
+	req = valid
+	req.OutputType = "png"
+	req.Thumbnail = &Thumbnail{Aspect: 1, Width: 100, Height: 100}
+	if err := req.Validate(); err != nil {
+		t.Errorf("expected a valid thumbnail request, got %v", err)
+	}
+}
+
+func TestConvertValidatesBeforeSending(t *testing.T) {
+	calls := 0
+	server := newMockConvertServer(t, func(body map[string]interface{}) string {
+		calls++
+		return `{"endConvert":true,"percent":100}`
+	})
+	defer server.Close()
+
+	conv := newTestConverter(t, server.URL)
+	if _, err := conv.Convert(context.Background(), ConvertRequest{FileType: "docx", Key: "key", OutputType: "pptx", URL: "https://storage.example.com/report.docx"}); !errors.Is(err, ErrInvalidConvertRequest) {
+		t.Errorf("expected ErrInvalidConvertRequest, got %v", err)
+	}
+
+	if calls != 0 {
+		t.Errorf("expected no request to be sent, got %d", calls)
+	}
+}
+
+func TestEditorFileTypeAfterConversion(t *testing.T) {
+	tests := []struct {
+		ext      string
+		expected string
+		err      error
+	}{
+		{"doc", "docx", nil},
+		{".XLS", "xlsx", nil},
+		{"ppt", "pptx", nil},
+		{"docx", "docx", nil},
+		{"odt", "odt", nil},
+		{"pdf", "pdf", nil},
+		{"exe", "", ErrOnlyofficeExtensionNotSupported},
+	}
+
+	for _, test := range tests {
+		fileType, err := EditorFileTypeAfterConversion(test.ext)
+		if fileType != test.expected || !errors.Is(err, test.err) {
+			t.Errorf("EditorFileTypeAfterConversion(%q) = %q, %v; expected %q, %v", test.ext, fileType, err, test.expected, test.err)
+		}
+	}
+}
+
+func TestEditorFileTypeAfterConversionConfigured(t *testing.T) {
+	util := NewOnlyofficeFileUtility(WithDocumentTypeOverrides(map[string]string{"key": "slide"}))
+	if fileType, err := util.EditorFileTypeAfterConversion("KEY"); err != nil || fileType != "key" {
+		t.Errorf("EditorFileTypeAfterConversion(%q) = %q, %v; expected %q", "KEY", fileType, err, "key")
+	}
+
+	if _, err := EditorFileTypeAfterConversion("key"); !errors.Is(err, ErrOnlyofficeExtensionNotSupported) {
+		t.Errorf("expected the package helper to use the built-in maps, got %v", err)
+	}
+}
+
+func TestEditableTargetForViewOnly(t *testing.T) {
+	tests := []struct {
+		ext      string
+		expected string
+		err      error
+	}{
+		{"pdf", "docx", nil},
+		{".XPS", "docx", nil},
+		{"xlsb", "xlsx", nil},
+		{"djvu", "", ErrNoEditableTarget},
+		{"docx", "", ErrNoEditableTarget},
+		{"exe", "", ErrOnlyofficeExtensionNotSupported},
+	}
+
+	for _, test := range tests {
+		target, err := EditableTargetForViewOnly(test.ext)
+		if target != test.expected || !errors.Is(err, test.err) {
+			t.Errorf("EditableTargetForViewOnly(%q) = %q, %v; expected %q, %v", test.ext, target, err, test.expected, test.err)
+		}
+	}
+}
+
+func TestConvertServerError(t *testing.T) {
+	server := newMockConvertServer(t, func(body map[string]interface{}) string {
+		return `{"error":-4}`
+	})
+	defer server.Close()
+
+	conv := newTestConverter(t, server.URL)
+	req := ConvertRequest{FileType: "doc", Key: "key", OutputType: "docx", URL: "https://storage.example.com/a.doc"}
+	_, err := conv.Convert(context.Background(), req)
+
+	var serverErr *ServerError
+	if !errors.Is(err, ErrConversionFailed) || !errors.As(err, &serverErr) || serverErr.Code != -4 {
+		t.Errorf("expected ErrConversionFailed with code -4, got %v", err)
+	}
+}
+
+func TestConvertReader(t *testing.T) {
+	manager := NewOnlyofficeJWTManager("secret")
+	content := []byte("PK\x03\x04 local document")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("could not parse the multipart body: %v", err)
+			return
+		}
+
+		var req ConvertRequest
+		if err := json.Unmarshal([]byte(r.FormValue("request")), &req); err != nil {
+			t.Errorf("could not decode the request part: %v", err)
+		}
+
+		if req.FileType != "docx" || req.OutputType != "pdf" || ValidateDocumentKey(req.Key) != nil {
+			t.Errorf("unexpected request part %+v", req)
+		}
+
+		if err := manager.Verify(req.Token, nil); err != nil {
+			t.Errorf("expected a signed request, got %v", err)
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("expected a file part, got %v", err)
+			return
+		}
+		defer file.Close()
+
+		buf, _ := io.ReadAll(file)
+		if !bytes.Equal(buf, content) || header.Filename != "file.docx" {
+			t.Errorf("unexpected file part %q (%q)", buf, header.Filename)
+		}
+
+		w.Write([]byte(`{"endConvert":true,"fileType":"pdf","fileUrl":"https://docs.example.com/output.pdf","percent":100}`))
+	}))
+	defer server.Close()
+
+	conv := newTestConverter(t, server.URL, WithJWTManager(manager))
+	resp, err := conv.ConvertReader(context.Background(), bytes.NewReader(content), ".DOCX", "pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !resp.EndConvert || resp.FileURL != "https://docs.example.com/output.pdf" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	limited := newTestConverter(t, server.URL, WithJWTManager(manager), WithExtensionSizeLimits(map[string]int64{"docx": 4}))
+	if _, err := limited.ConvertReader(context.Background(), bytes.NewReader(content), "docx", "pdf"); !errors.Is(err, ErrInvalidContentLength) {
+		t.Errorf("expected ErrInvalidContentLength, got %v", err)
+	}
+
+	if _, err := conv.ConvertReader(context.Background(), bytes.NewReader(content), "docx", "xlsx"); !errors.Is(err, ErrInvalidConvertRequest) {
+		t.Errorf("expected ErrInvalidConvertRequest, got %v", err)
+	}
+}
+
+func TestConvertSignsRequest(t *testing.T) {
+	manager := NewOnlyofficeJWTManager("secret")
+	var received map[string]interface{}
+	server := newMockConvertServer(t, func(body map[string]interface{}) string {
+		received = body
+		return `{"endConvert":true,"fileUrl":"https://docs.example.com/output.pdf","percent":100}`
+	})
+	defer server.Close()
+
+	conv := newTestConverter(t, server.URL, WithJWTManager(manager))
+	req := ConvertRequest{FileType: "docx", Key: "key", OutputType: "pdf", URL: "https://storage.example.com/a.docx"}
+	if _, err := conv.Convert(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	token, _ := received["token"].(string)
+	var signed ConvertRequest
+	if err := manager.Verify(token, &signed); err != nil || signed.Key != "key" {
+		t.Errorf("expected a signed request, got %+v, %v", signed, err)
+	}
+}
+
+func TestConvertAndWaitCancel(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		opts    []Option
+		dropped bool
+	}{
+		{"generated key", "", []Option{WithCancelAbandonedConversions(true)}, true},
+		{"caller key", "key", []Option{WithCancelAbandonedConversions(true)}, false},
+		{"disabled", "", nil, false},
+	}
+
+	for _, test := range tests {
+		ctx, cancel := context.WithCancel(context.Background())
+		var converted string
+		dropped := make(chan string, 1)
+		mux := http.NewServeMux()
+		mux.HandleFunc(_OnlyofficeConvertServicePath, func(w http.ResponseWriter, r *http.Request) {
+			var req ConvertRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			converted = req.Key
+			cancel()
+			w.Write([]byte(`{"endConvert":false,"percent":10}`))
+		})
+		mux.HandleFunc(_OnlyofficeCommandServicePath, func(w http.ResponseWriter, r *http.Request) {
+			var cmd commandRequest
+			if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil || cmd.C != "drop" {
+				t.Errorf("%s: expected a drop command, got %+v, %v", test.name, cmd, err)
+			}
+
+			dropped <- cmd.Key
+			w.Write([]byte(`{"error":0,"key":"` + cmd.Key + `"}`))
+		})
+		server := httptest.NewServer(mux)
+
+		conv := newTestConverter(t, server.URL, test.opts...)
+		req := ConvertRequest{FileType: "doc", Key: test.key, OutputType: "docx", URL: "https://storage.example.com/file.doc"}
+		if _, err := conv.ConvertAndWait(ctx, req); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled, got %v", test.name, err)
+		}
+		server.Close()
+
+		if ValidateDocumentKey(converted) != nil || (test.key != "" && converted != test.key) {
+			t.Errorf("%s: unexpected conversion key %q", test.name, converted)
+		}
+
+		select {
+		case key := <-dropped:
+			if !test.dropped || key != converted {
+				t.Errorf("%s: unexpected drop of %q", test.name, key)
+			}
+		default:
+			if test.dropped {
+				t.Errorf("%s: expected the conversion to be dropped", test.name)
+			}
+		}
+	}
+}
+
+func TestConvertReaderCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var converted string
+	dropped := make(chan string, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(_OnlyofficeConvertServicePath, func(w http.ResponseWriter, r *http.Request) {
+		var req ConvertRequest
+		json.Unmarshal([]byte(r.FormValue("request")), &req)
+		converted = req.Key
+		cancel()
+		<-r.Context().Done()
+	})
+	mux.HandleFunc(_OnlyofficeCommandServicePath, func(w http.ResponseWriter, r *http.Request) {
+		var cmd commandRequest
+		json.NewDecoder(r.Body).Decode(&cmd)
+		dropped <- cmd.Key
+		w.Write([]byte(`{"error":0,"key":"` + cmd.Key + `"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	conv := newTestConverter(t, server.URL, WithCancelAbandonedConversions(true))
+	if _, err := conv.ConvertReader(ctx, strings.NewReader("content"), "docx", "pdf"); err == nil {
+		t.Fatal("expected the cancelled conversion to fail")
+	}
+
+	select {
+	case key := <-dropped:
+		if key == "" || key != converted {
+			t.Errorf("expected %q to be dropped, got %q", converted, key)
+		}
+	default:
+		t.Error("expected the conversion to be dropped")
+	}
+}
+
+func TestCancelConversion(t *testing.T) {
+	server := newMockCommandServer(t)
+	defer server.Close()
+
+	conv := newTestConverter(t, server.URL)
+	if err := conv.CancelConversion(context.Background(), "key"); !errors.Is(err, ErrCommandFailed) {
+		t.Errorf("expected ErrCommandFailed for an unknown command, got %v", err)
+	}
+
+	if err := conv.CancelConversion(context.Background(), "bad key"); !errors.Is(err, ErrInvalidDocumentKey) {
+		t.Errorf("expected ErrInvalidDocumentKey, got %v", err)
+	}
+}
+
+func TestCancelConversionRequest(t *testing.T) {
+	var received commandRequest
+	mux := http.NewServeMux()
+	mux.HandleFunc(_OnlyofficeCommandServicePath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s with content type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("could not decode command: %v", err)
+		}
+
+		w.Write([]byte(`{"error":0,"key":"` + received.Key + `"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	jwt := NewOnlyofficeJWTManager("secret")
+	conv := newTestConverter(t, server.URL, WithJWTManager(jwt))
+	if err := conv.CancelConversion(context.Background(), "conversion-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if received.C != "drop" || received.Key != "conversion-1" {
+		t.Errorf("expected a drop command for conversion-1, got %+v", received)
+	}
+
+	var signed commandRequest
+	if err := jwt.Verify(received.Token, &signed); err != nil || signed.C != "drop" || signed.Key != "conversion-1" {
+		t.Errorf("expected a signed drop command, got %+v, %v", signed, err)
+	}
+}
+
+func TestEditableTargetForViewOnlyConfigured(t *testing.T) {
+	util := NewOnlyofficeFileUtility(WithExtensionSource(testExtensionSource{
+		"pdf": {docType: OnlyofficeWordType, capability: CapabilityEditable},
+		"xps": {docType: OnlyofficeWordType, capability: CapabilityViewOnly},
+	}))
+
+	if _, err := util.EditableTargetForViewOnly("pdf"); !errors.Is(err, ErrNoEditableTarget) {
+		t.Errorf("expected pdf to be editable with the configured source, got %v", err)
+	}
+
+	if target, err := util.EditableTargetForViewOnly("xps"); err != nil || target != "docx" {
+		t.Errorf("EditableTargetForViewOnly(%q) = %q, %v; expected %q", "xps", target, err, "docx")
+	}
+
+	if _, err := util.EditableTargetForViewOnly("djvu"); !errors.Is(err, ErrOnlyofficeExtensionNotSupported) {
+		t.Errorf("expected djvu to be unsupported by the configured source, got %v", err)
+	}
+}
+
+func TestEstimateConversionTimeout(t *testing.T) {
+	small := EstimateConversionTimeout(1<<20, OnlyofficeCellType)
+	large := EstimateConversionTimeout(50<<20, OnlyofficeCellType)
+	if large <= small {
+		t.Errorf("expected a larger file to take longer, got %v and %v", small, large)
+	}
+
+	if cell, word := EstimateConversionTimeout(50<<20, OnlyofficeCellType), EstimateConversionTimeout(50<<20, OnlyofficeWordType); cell <= word {
+		t.Errorf("expected spreadsheets to take longer than documents, got %v and %v", cell, word)
+	}
+
+	if unknown := EstimateConversionTimeout(-1, "unknown"); unknown != _ConversionBaseTimeout {
+		t.Errorf("expected the base timeout, got %v", unknown)
+	}
+
+	if huge := EstimateConversionTimeout(1<<50, OnlyofficeCellType); huge != _ConversionMaxTimeout {
+		t.Errorf("expected the timeout to be capped, got %v", huge)
+	}
+}
+
+func TestConvertAndWaitDeadline(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(_OnlyofficeConvertServicePath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"endConvert":false,"percent":10}`))
+	})
+	mux.HandleFunc(_OnlyofficeCommandServicePath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error":0}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	conv := newTestConverter(t, server.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := conv.ConvertAndWait(ctx, ConvertRequest{FileType: "xlsx", Key: "key", OutputType: "pdf", URL: "https://storage.example.com/a.xlsx", Size: 1 << 30})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the caller deadline to be respected, took %v", elapsed)
+	}
+}
+
+func TestConvertAndWaitDeadlineRequiresSize(t *testing.T) {
+	conv := newTestConverter(t, "https://docs.example.com")
+	req := ConvertRequest{FileType: "xlsx", Key: "key", OutputType: "pdf", URL: "https://storage.example.com/a.xlsx"}
+
+	ctx, cancel := conv.waitContext(context.Background(), req)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("expected no deadline without a request size")
+	}
+
+	req.Size = 1 << 20
+	ctx, cancel = conv.waitContext(context.Background(), req)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > EstimateConversionTimeout(req.Size, OnlyofficeCellType) {
+		t.Errorf("expected the estimated deadline for a known size, got %v, %v", deadline, ok)
+	}
+
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Second)
+	defer cancelParent()
+	ctx, cancel = conv.waitContext(parent, req)
+	defer cancel()
+	if ctx != parent {
+		t.Error("expected a tighter caller deadline to be kept")
+	}
+}
+
+func TestConvertRequestValidateExtensionOptions(t *testing.T) {
+	req := ConvertRequest{FileType: "key", Key: "key", OutputType: "pptx", URL: "https://storage.example.com/deck.key"}
+	if err := req.Validate(); !errors.Is(err, ErrOnlyofficeExtensionNotSupported) {
+		t.Errorf("expected key to be unsupported by default, got %v", err)
+	}
+
+	overrides := WithDocumentTypeOverrides(map[string]string{"key": "slide"})
+	if err := req.Validate(overrides); err != nil {
+		t.Errorf("expected the override to make key convertible, got %v", err)
+	}
+
+	server := newMockConvertServer(t, func(body map[string]interface{}) string {
+		return `{"endConvert":true,"fileType":"pptx","fileUrl":"https://docs.example.com/output.pptx","percent":100}`
+	})
+	defer server.Close()
+
+	conv := newTestConverter(t, server.URL, overrides)
+	if _, err := conv.Convert(context.Background(), req); err != nil {
+		t.Errorf("expected the converter to validate with its overrides, got %v", err)
+	}
+}
+
+func TestConvertRequestCSVOptions(t *testing.T) {
+	valid := ConvertRequest{
+		FileType:   "xlsx",
+		Key:        "key",
+		OutputType: "csv",
+		URL:        "https://storage.example.com/data.xlsx",
+		CodePage:   65001,
+		Delimiter:  CSVDelimiterSemicolon,
+	}
+
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("expected a valid csv conversion, got %v", err)
+	}
+
+	encoded, err := json.Marshal(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(encoded), `"codePage":65001`) || !strings.Contains(string(encoded), `"delimiter":2`) {
+		t.Errorf("expected the csv options to be sent, got %s", encoded)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*ConvertRequest)
+		err    error
+	}{
+		{"delimiter", func(r *ConvertRequest) { r.Delimiter = 9 }, ErrInvalidCSVOption},
+		{"negative delimiter", func(r *ConvertRequest) { r.Delimiter = -1 }, ErrInvalidCSVOption},
+		{"code page", func(r *ConvertRequest) { r.CodePage = 12345 }, ErrInvalidCSVOption},
+		{"not csv", func(r *ConvertRequest) { r.OutputType = "pdf" }, ErrCSVOptionsNotSupported},
+		{"not cell", func(r *ConvertRequest) {
+			r.FileType, r.OutputType, r.URL = "docx", "txt", "https://storage.example.com/report.docx"
+		}, ErrCSVOptionsNotSupported},
+	}
+
+	for _, test := range tests {
+		req := valid
+		test.modify(&req)
+		if err := req.Validate(); !errors.Is(err, ErrInvalidConvertRequest) || !errors.Is(err, test.err) {
+			t.Errorf("%s: expected ErrInvalidConvertRequest and %v, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestConvertRequestAllowedOutputTypes(t *testing.T) {
+	req := ConvertRequest{FileType: "docx", Key: "key", OutputType: "pdf", URL: "https://storage.example.com/a.docx"}
+	if err := req.Validate(WithAllowedOutputTypes("odt", ".PDF")); err != nil {
+		t.Errorf("expected pdf to be allowed, got %v", err)
+	}
+
+	err := req.Validate(WithAllowedOutputTypes("docx", "odt"))
+	if !errors.Is(err, ErrOutputTypeNotAllowed) || !errors.Is(err, ErrInvalidConvertRequest) {
+		t.Errorf("expected ErrOutputTypeNotAllowed, got %v", err)
+	}
+
+	conv := newTestConverter(t, "https://docs.example.com", WithAllowedOutputTypes("docx"))
+	if _, err := conv.Convert(context.Background(), req); !errors.Is(err, ErrOutputTypeNotAllowed) {
+		t.Errorf("expected the converter to reject pdf, got %v", err)
+	}
+
+	if _, err := conv.ConvertReader(context.Background(), strings.NewReader("content"), "docx", "pdf"); !errors.Is(err, ErrOutputTypeNotAllowed) {
+		t.Errorf("expected the converter to reject pdf uploads, got %v", err)
+	}
+}
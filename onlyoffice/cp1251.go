@@ -0,0 +1,88 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// _CP1251HighRunes maps the Windows-1251 bytes 0x80-0xBF to runes. Bytes
+// 0xC0-0xFF are the contiguous Cyrillic letters U+0410-U+044F.
+var _CP1251HighRunes = [64]rune{
+	'Ђ', 'Ѓ', '‚', 'ѓ', '„', '…', '†', '‡', '€', '‰', 'Љ', '‹', 'Њ', 'Ќ', 'Ћ', 'Џ',
+	'ђ', '‘', '’', '“', '”', '•', '–', '—', utf8.RuneError, '™', 'љ', '›', 'њ', 'ќ', 'ћ', 'џ',
+	'\u00A0', 'Ў', 'ў', 'Ј', '¤', 'Ґ', '¦', '§', 'Ё', '©', 'Є', '«', '¬', '\u00AD', '®', 'Ї',
+	'°', '±', 'І', 'і', 'ґ', 'µ', '¶', '·', 'ё', '№', 'є', '»', 'ј', 'Ѕ', 'ѕ', 'ї',
+}
+
+// isLikelyCP1251 reports whether a string that is not valid UTF-8 looks like
+// Windows-1251 text: most of its non-ASCII bytes have to be Cyrillic letters.
+func isLikelyCP1251(text string) bool {
+	if utf8.ValidString(text) {
+		return false
+	}
+
+	var high, letters int
+	for i := 0; i < len(text); i++ {
+		b := text[i]
+		if b < 0x80 {
+			continue
+		}
+
+		if b == 0x98 {
+			return false
+		}
+
+		high++
+		if isCP1251Letter(b) {
+			letters++
+		}
+	}
+
+	return high > 0 && letters*2 >= high
+}
+
+func isCP1251Letter(b byte) bool {
+	switch b {
+	case 0x80, 0x81, 0x83, 0x8A, 0x8C, 0x8D, 0x8E, 0x8F, 0x90, 0x9A, 0x9C, 0x9D, 0x9E, 0x9F,
+		0xA1, 0xA2, 0xA3, 0xA5, 0xA8, 0xAA, 0xAF, 0xB2, 0xB3, 0xB4, 0xB8, 0xBA, 0xBC, 0xBD, 0xBE, 0xBF:
+		return true
+	default:
+		return b >= 0xC0
+	}
+}
+
+// decodeCP1251 transcodes Windows-1251 bytes to UTF-8.
+func decodeCP1251(text string) string {
+	var decoded strings.Builder
+	decoded.Grow(len(text) * 2)
+	for i := 0; i < len(text); i++ {
+		switch b := text[i]; {
+		case b < 0x80:
+			decoded.WriteByte(b)
+		case b < 0xC0:
+			decoded.WriteRune(_CP1251HighRunes[b-0x80])
+		default:
+			decoded.WriteRune(rune(b-0xC0) + 'А')
+		}
+	}
+
+	return decoded.String()
+}
@@ -0,0 +1,103 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// readCloser combines a reader with the closer of the response it reads from.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func (u fileUtility) Download(ctx context.Context, url string, offset int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("%w: negative offset %d", ErrInvalidRange, offset)
+	}
+
+	req, err := u.options.newRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := u.options.do(HTTPPurposeDownload, req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDownloadFailed, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if start := contentRangeStart(resp.Header.Get("Content-Range")); start != offset {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%w: range starts at %d, expected %d", ErrDownloadFailed, start, offset)
+		}
+
+		return resp.Body, nil
+	case http.StatusOK:
+		// The server ignored the Range header and sent the whole file.
+		if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+			resp.Body.Close()
+			if err == io.EOF {
+				return nil, fmt.Errorf("%w: offset %d is past the end of the file", ErrInvalidRange, offset)
+			}
+
+			return nil, fmt.Errorf("%w: %w", ErrDownloadFailed, err)
+		}
+
+		return readCloser{Reader: resp.Body, Closer: resp.Body}, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: offset %d is past the end of the file", ErrInvalidRange, offset)
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: unexpected status %d", ErrDownloadFailed, resp.StatusCode)
+	}
+}
+
+// contentRangeStart parses the first byte position of a "bytes start-end/size"
+// Content-Range header, returning -1 when it is malformed.
+func contentRangeStart(contentRange string) int64 {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(contentRange), "bytes ")
+	if !ok {
+		return -1
+	}
+
+	first, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return -1
+	}
+
+	start, err := strconv.ParseInt(strings.TrimSpace(first), 10, 64)
+	if err != nil {
+		return -1
+	}
+
+	return start
+}
@@ -0,0 +1,102 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDownloadResume(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789"), 100)
+	util := NewOnlyofficeFileUtility()
+
+	for _, honorRange := range []bool{true, false} {
+		server := newRangeServer(payload, honorRange)
+		for _, offset := range []int64{0, 1, 512, int64(len(payload) - 1)} {
+			body, err := util.Download(context.Background(), server.URL, offset)
+			if err != nil {
+				t.Errorf("Download(%d) (range %v) returned %v", offset, honorRange, err)
+				continue
+			}
+
+			got, err := io.ReadAll(body)
+			body.Close()
+			if err != nil {
+				t.Errorf("reading Download(%d) (range %v) returned %v", offset, honorRange, err)
+			} else if !bytes.Equal(got, payload[offset:]) {
+				t.Errorf("Download(%d) (range %v) returned %d bytes; expected %d", offset, honorRange, len(got), len(payload)-int(offset))
+			}
+		}
+		server.Close()
+	}
+}
+
+func TestDownloadRangeHeader(t *testing.T) {
+	var header string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("Range")
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	util := NewOnlyofficeFileUtility()
+	for offset, expected := range map[int64]string{0: "", 3: "bytes=3-"} {
+		body, err := util.Download(context.Background(), server.URL, offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body.Close()
+
+		if header != expected {
+			t.Errorf("Download(%d) sent Range %q; expected %q", offset, header, expected)
+		}
+	}
+}
+
+func TestDownloadInvalidRange(t *testing.T) {
+	payload := []byte("content")
+	util := NewOnlyofficeFileUtility()
+
+	for _, honorRange := range []bool{true, false} {
+		server := newRangeServer(payload, honorRange)
+		if _, err := util.Download(context.Background(), server.URL, 100); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("expected ErrInvalidRange past the end (range %v), got %v", honorRange, err)
+		}
+		server.Close()
+	}
+
+	if _, err := util.Download(context.Background(), "http://127.0.0.1", -1); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange for a negative offset, got %v", err)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failing.Close()
+
+	if _, err := util.Download(context.Background(), failing.URL, 0); !errors.Is(err, ErrDownloadFailed) {
+		t.Errorf("expected ErrDownloadFailed for a 404, got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import "errors"
+
+// Base errors group the package errors by category. Every sentinel below
+// matches its base with errors.Is, e.g. errors.Is(ErrInvalidContentLength,
+// ErrOnlyofficeValidation) is true, while still matching itself.
+var (
+	// ErrOnlyofficeValidation is the base of errors caused by invalid input.
+	ErrOnlyofficeValidation = errors.New("onlyoffice validation error")
+	// ErrOnlyofficeNetwork is the base of errors talking to the document server or storage.
+	ErrOnlyofficeNetwork = errors.New("onlyoffice network error")
+	// ErrOnlyofficeConversion is the base of document conversion errors.
+	ErrOnlyofficeConversion = errors.New("onlyoffice conversion error")
+	// ErrOnlyofficeAuth is the base of token errors.
+	ErrOnlyofficeAuth = errors.New("onlyoffice auth error")
+)
+
+// Validation errors.
+var (
+	ErrInvalidContentLength            = newCategorizedError(ErrOnlyofficeValidation, "could not perform api actions due to exceeding content-length")
+	ErrOnlyofficeExtensionNotSupported = newCategorizedError(ErrOnlyofficeValidation, "file extension is not supported")
+	ErrInvalidServerURL                = newCategorizedError(ErrOnlyofficeValidation, "invalid document server url")
+	ErrInvalidCallbackURL              = newCategorizedError(ErrOnlyofficeValidation, "callback download url is not allowed")
+	ErrInvalidLocale                   = newCategorizedError(ErrOnlyofficeValidation, "invalid locale")
+	ErrUnknownDocumentType             = newCategorizedError(ErrOnlyofficeValidation, "unknown document type")
+	ErrInvalidGoBackURL                = newCategorizedError(ErrOnlyofficeValidation, "goback url is not allowed")
+	ErrCorruptDocument                 = newCategorizedError(ErrOnlyofficeValidation, "document is truncated or corrupt")
+	ErrFileContentMismatch             = newCategorizedError(ErrOnlyofficeValidation, "file content does not match its extension")
+	ErrInvalidDocumentKey              = newCategorizedError(ErrOnlyofficeValidation, "invalid document key")
+	ErrInvalidConvertRequest           = newCategorizedError(ErrOnlyofficeValidation, "invalid conversion request")
+	ErrInvalidEditorEntry              = newCategorizedError(ErrOnlyofficeValidation, "invalid recent or template entry")
+	ErrInvalidTitle                    = newCategorizedError(ErrOnlyofficeValidation, "invalid document title")
+	ErrInvalidLogoURL                  = newCategorizedError(ErrOnlyofficeValidation, "logo url is not allowed")
+	ErrExtensionOverlap                = newCategorizedError(ErrOnlyofficeValidation, "extension is listed in more than one capability map")
+	ErrUnknownRole                     = newCategorizedError(ErrOnlyofficeValidation, "unknown user role")
+	ErrCallbackKeyMismatch             = newCategorizedError(ErrOnlyofficeValidation, "callback key does not match the document key")
+	ErrInvalidConfig                   = newCategorizedError(ErrOnlyofficeValidation, "config does not match the editor config schema")
+	ErrInvalidReferenceData            = newCategorizedError(ErrOnlyofficeValidation, "invalid reference data")
+	ErrUnsupportedContentEncoding      = newCategorizedError(ErrOnlyofficeValidation, "content encoding cannot be decoded")
+	ErrInvalidEditorEvent              = newCategorizedError(ErrOnlyofficeValidation, "invalid editor event")
+	ErrCSVOptionsNotSupported          = newCategorizedError(ErrOnlyofficeValidation, "code page and delimiter are only supported for csv conversions")
+	ErrInvalidCSVOption                = newCategorizedError(ErrOnlyofficeValidation, "unsupported csv code page or delimiter")
+	ErrInvalidRange                    = newCategorizedError(ErrOnlyofficeValidation, "requested range is not satisfiable")
+	ErrOutputTypeNotAllowed            = newCategorizedError(ErrOnlyofficeValidation, "conversion output type is not allowed")
+)
+
+// Network errors.
+var (
+	ErrServerUnreachable = newCategorizedError(ErrOnlyofficeNetwork, "document server is unreachable")
+	ErrServerError       = newCategorizedError(ErrOnlyofficeNetwork, "document server returned an error")
+	ErrCommandFailed     = newCategorizedError(ErrOnlyofficeNetwork, "document server command failed")
+	ErrDownloadFailed    = newCategorizedError(ErrOnlyofficeNetwork, "file download failed")
+)
+
+// Conversion errors.
+var (
+	ErrConversionFailed    = newCategorizedError(ErrOnlyofficeConversion, "document conversion failed")
+	ErrConvertedURLExpired = newCategorizedError(ErrOnlyofficeConversion, "converted file url has expired")
+	ErrNoEditableTarget    = newCategorizedError(ErrOnlyofficeConversion, "file cannot be converted to an editable format")
+)
+
+// Auth errors.
+var (
+	ErrInvalidToken = newCategorizedError(ErrOnlyofficeAuth, "invalid jwt token")
+	ErrTokenExpired = newCategorizedError(ErrOnlyofficeAuth, "jwt token has expired")
+)
+
+// categorizedError is a sentinel error that also matches its base error.
+type categorizedError struct {
+	message  string
+	category error
+}
+
+func newCategorizedError(category error, message string) error {
+	return &categorizedError{message: message, category: category}
+}
+
+func (e *categorizedError) Error() string {
+	return e.message
+}
+
+func (e *categorizedError) Unwrap() error {
+	return e.category
+}
@@ -0,0 +1,72 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorHierarchy(t *testing.T) {
+	bases := []error{ErrOnlyofficeValidation, ErrOnlyofficeNetwork, ErrOnlyofficeConversion, ErrOnlyofficeAuth}
+	tests := []struct {
+		err  error
+		base error
+	}{
+		{ErrInvalidContentLength, ErrOnlyofficeValidation},
+		{ErrOnlyofficeExtensionNotSupported, ErrOnlyofficeValidation},
+		{ErrInvalidDocumentKey, ErrOnlyofficeValidation},
+		{ErrInvalidConvertRequest, ErrOnlyofficeValidation},
+		{ErrCallbackKeyMismatch, ErrOnlyofficeValidation},
+		{ErrServerUnreachable, ErrOnlyofficeNetwork},
+		{ErrCommandFailed, ErrOnlyofficeNetwork},
+		{ErrServerError, ErrOnlyofficeNetwork},
+		{ErrConversionFailed, ErrOnlyofficeConversion},
+		{ErrConvertedURLExpired, ErrOnlyofficeConversion},
+		{ErrInvalidToken, ErrOnlyofficeAuth},
+		{ErrTokenExpired, ErrOnlyofficeAuth},
+	}
+
+	for _, test := range tests {
+		wrapped := fmt.Errorf("%w: details", test.err)
+		if !errors.Is(wrapped, test.err) {
+			t.Errorf("expected %q to match itself when wrapped", test.err)
+		}
+
+		for _, base := range bases {
+			if matches := errors.Is(wrapped, base); matches != (base == test.base) {
+				t.Errorf("errors.Is(%q, %q) = %v; expected %v", test.err, base, matches, base == test.base)
+			}
+		}
+	}
+
+	if errors.Is(ErrInvalidContentLength, ErrOnlyofficeExtensionNotSupported) {
+		t.Error("expected sibling errors not to match each other")
+	}
+
+	if ErrInvalidContentLength.Error() != "could not perform api actions due to exceeding content-length" {
+		t.Errorf("expected the message to be preserved, got %q", ErrInvalidContentLength.Error())
+	}
+
+	var serverErr error = &ServerError{Code: -3}
+	if err := fmt.Errorf("%w: %w", ErrConversionFailed, serverErr); !errors.Is(err, ErrOnlyofficeConversion) || !errors.Is(err, ErrOnlyofficeNetwork) {
+		t.Errorf("expected a failed conversion to match both its bases, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// EditorEventType is the name of an event raised by the editor frame.
+type EditorEventType string
+
+const (
+	EditorEventAppReady            EditorEventType = "onAppReady"
+	EditorEventDocumentReady       EditorEventType = "onDocumentReady"
+	EditorEventDocumentStateChange EditorEventType = "onDocumentStateChange"
+	EditorEventError               EditorEventType = "onError"
+	EditorEventWarning             EditorEventType = "onWarning"
+	EditorEventMetaChange          EditorEventType = "onMetaChange"
+	EditorEventRequestClose        EditorEventType = "onRequestClose"
+	EditorEventRequestRename       EditorEventType = "onRequestRename"
+	EditorEventRequestSaveAs       EditorEventType = "onRequestSaveAs"
+)
+
+// EditorEvent is an editor postMessage event proxied to the server. Data always
+// holds the raw event data; the typed fields are filled for the known types:
+// Code and Description for onError and onWarning, Modified for
+// onDocumentStateChange, Title for onMetaChange, onRequestRename and
+// onRequestSaveAs, Favorite for onMetaChange and FileType and URL for
+// onRequestSaveAs. The onRequestSaveAs URL comes from the browser and is
+// untrusted: check it with ValidateCallbackDownloadURL before fetching it.
+type EditorEvent struct {
+	Type        EditorEventType `json:"type"`
+	Data        json.RawMessage `json:"data,omitempty"`
+	Code        int             `json:"code,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Modified    bool            `json:"modified,omitempty"`
+	Title       string          `json:"title,omitempty"`
+	Favorite    *bool           `json:"favorite,omitempty"`
+	FileType    string          `json:"fileType,omitempty"`
+	URL         string          `json:"url,omitempty"`
+}
+
+// Known reports whether the event type is one ParseEditorEvent decodes.
+func (e EditorEvent) Known() bool {
+	switch e.Type {
+	case EditorEventAppReady, EditorEventDocumentReady, EditorEventDocumentStateChange, EditorEventError,
+		EditorEventWarning, EditorEventMetaChange, EditorEventRequestClose, EditorEventRequestRename,
+		EditorEventRequestSaveAs:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseEditorEvent decodes a {"event": ..., "data": ...} message. Unknown event
+// types are not an error: they are returned with their raw Data only. Messages
+// without an event name or with malformed data for a known type report
+// ErrInvalidEditorEvent.
+func ParseEditorEvent(message []byte) (EditorEvent, error) {
+	var envelope struct {
+		Event tolerantString  `json:"event"`
+		Data  json.RawMessage `json:"data"`
+	}
+
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		return EditorEvent{}, fmt.Errorf("%w: %w", ErrInvalidEditorEvent, err)
+	}
+
+	if envelope.Event == "" {
+		return EditorEvent{}, fmt.Errorf("%w: missing event name", ErrInvalidEditorEvent)
+	}
+
+	event := EditorEvent{Type: EditorEventType(envelope.Event), Data: envelope.Data}
+	if err := event.decodeData(); err != nil {
+		return EditorEvent{}, fmt.Errorf("%w: %s: %w", ErrInvalidEditorEvent, event.Type, err)
+	}
+
+	return event, nil
+}
+
+func (e *EditorEvent) decodeData() error {
+	if len(e.Data) == 0 || string(e.Data) == "null" {
+		return nil
+	}
+
+	switch e.Type {
+	case EditorEventError:
+		var data struct {
+			Code        tolerantInt    `json:"errorCode"`
+			Description tolerantString `json:"errorDescription"`
+		}
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			return err
+		}
+
+		e.Code, e.Description = int(data.Code), string(data.Description)
+	case EditorEventWarning:
+		var data struct {
+			Code        tolerantInt    `json:"warningCode"`
+			Description tolerantString `json:"warningDescription"`
+		}
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			return err
+		}
+
+		e.Code, e.Description = int(data.Code), string(data.Description)
+	case EditorEventDocumentStateChange:
+		return json.Unmarshal(e.Data, &e.Modified)
+	case EditorEventRequestRename:
+		var title tolerantString
+		if err := json.Unmarshal(e.Data, &title); err != nil {
+			return err
+		}
+
+		e.Title = string(title)
+	case EditorEventMetaChange:
+		var data struct {
+			Title    tolerantString `json:"title"`
+			Favorite *bool          `json:"favorite"`
+		}
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			return err
+		}
+
+		e.Title, e.Favorite = string(data.Title), data.Favorite
+	case EditorEventRequestSaveAs:
+		var data struct {
+			FileType tolerantString `json:"fileType"`
+			Title    tolerantString `json:"title"`
+			URL      tolerantString `json:"url"`
+		}
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			return err
+		}
+
+		e.FileType, e.Title, e.URL = string(data.FileType), string(data.Title), string(data.URL)
+	}
+
+	return nil
+}
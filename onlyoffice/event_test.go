@@ -0,0 +1,106 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseEditorEvent(t *testing.T) {
+	favorite := true
+	tests := []struct {
+		message  string
+		expected EditorEvent
+	}{
+		{`{"event":"onDocumentReady"}`, EditorEvent{Type: EditorEventDocumentReady}},
+		{`{"event":"onAppReady","data":null}`, EditorEvent{Type: EditorEventAppReady}},
+		{`{"event":"onError","data":{"errorCode":-82,"errorDescription":"Conversion error"}}`,
+			EditorEvent{Type: EditorEventError, Code: -82, Description: "Conversion error"}},
+		{`{"event":"onWarning","data":{"warningCode":"-4","warningDescription":"Download failed"}}`,
+			EditorEvent{Type: EditorEventWarning, Code: -4, Description: "Download failed"}},
+		{`{"event":"onDocumentStateChange","data":true}`, EditorEvent{Type: EditorEventDocumentStateChange, Modified: true}},
+		{`{"event":"onRequestRename","data":"Budget 2026.xlsx"}`, EditorEvent{Type: EditorEventRequestRename, Title: "Budget 2026.xlsx"}},
+		{`{"event":"onMetaChange","data":{"title":"Report.docx","favorite":true}}`,
+			EditorEvent{Type: EditorEventMetaChange, Title: "Report.docx", Favorite: &favorite}},
+		{`{"event":"onRequestSaveAs","data":{"fileType":"pdf","title":"Report.pdf","url":"https://docs.example.com/cache/report.pdf"}}`,
+			EditorEvent{Type: EditorEventRequestSaveAs, FileType: "pdf", Title: "Report.pdf", URL: "https://docs.example.com/cache/report.pdf"}},
+		{`{"event":"onPluginsReady","data":{"plugins":["ai"]}}`, EditorEvent{Type: "onPluginsReady"}},
+	}
+
+	for _, test := range tests {
+		event, err := ParseEditorEvent([]byte(test.message))
+		if err != nil {
+			t.Errorf("ParseEditorEvent(%s) returned %v", test.message, err)
+			continue
+		}
+
+		event.Data = nil
+		if event.Type != test.expected.Type || event.Code != test.expected.Code || event.Description != test.expected.Description ||
+			event.Modified != test.expected.Modified || event.Title != test.expected.Title || event.FileType != test.expected.FileType ||
+			event.URL != test.expected.URL || (event.Favorite == nil) != (test.expected.Favorite == nil) ||
+			(event.Favorite != nil && *event.Favorite != *test.expected.Favorite) {
+			t.Errorf("ParseEditorEvent(%s) = %+v; expected %+v", test.message, event, test.expected)
+		}
+	}
+}
+
+func TestEditorEventJSON(t *testing.T) {
+	event, err := ParseEditorEvent([]byte(`{"event":"onRequestSaveAs","data":{"fileType":"pdf","title":"Report.pdf","url":"https://docs.example.com/r.pdf"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"type":"onRequestSaveAs","data":{"fileType":"pdf","title":"Report.pdf","url":"https://docs.example.com/r.pdf"},` +
+		`"title":"Report.pdf","fileType":"pdf","url":"https://docs.example.com/r.pdf"}`
+	if string(encoded) != expected {
+		t.Errorf("expected %s, got %s", expected, encoded)
+	}
+}
+
+func TestParseEditorEventUnknown(t *testing.T) {
+	event, err := ParseEditorEvent([]byte(`{"event":"onSomethingNew","data":{"anything":[1,2,3]}}`))
+	if err != nil {
+		t.Fatalf("expected an unknown event to be accepted, got %v", err)
+	}
+
+	if event.Known() || event.Type != "onSomethingNew" || string(event.Data) != `{"anything":[1,2,3]}` {
+		t.Errorf("unexpected unknown event %+v", event)
+	}
+}
+
+func TestParseEditorEventInvalid(t *testing.T) {
+	for _, message := range []string{
+		``,
+		`not json`,
+		`{"data":{}}`,
+		`{"event":"onDocumentStateChange","data":"yes"}`,
+		`{"event":"onError","data":[1]}`,
+	} {
+		if _, err := ParseEditorEvent([]byte(message)); !errors.Is(err, ErrInvalidEditorEvent) {
+			t.Errorf("ParseEditorEvent(%q) = %v; expected ErrInvalidEditorEvent", message, err)
+		}
+	}
+}
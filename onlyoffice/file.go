@@ -0,0 +1,788 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	_LenientExtensionSegments = 3
+	_TempFilenameMaxLength    = 64
+	_TempFilenameFallback     = "file"
+)
+
+type OnlyofficeFileUtility interface {
+	// ValidateFileSize checks the size reported by a HEAD request. For encoded
+	// responses the limit applies to the transfer size unless the server sends
+	// X-Uncompressed-Length or EnforceDecompressedSize is enabled. Urls on
+	// TrustedHosts are accepted without a request.
+	ValidateFileSize(ctx context.Context, limit int64, url string) error
+	// ValidateFileSizeAuto enforces the size limit of ext, falling back to the
+	// limit of its document type and then to the default limit.
+	ValidateFileSizeAuto(ctx context.Context, url string, ext string) error
+	// ValidateCallbackFileSize trusts the size declared by the callback body and
+	// only falls back to a HEAD request on the callback url when it is missing.
+	// The url is checked with ValidateCallbackDownloadURL against CallbackHosts
+	// first, returning ErrInvalidCallbackURL without a request. Without
+	// CallbackHosts the request is sent with the HTTPPurposeCallback client.
+	ValidateCallbackFileSize(ctx context.Context, body CallbackBody, limit int64) error
+	// EscapeFilename replaces path separators with ":". UNC paths
+	// (\\server\share\a.docx) and device paths (\\?\C:\dir\a.docx) are reduced
+	// to their final component instead. With DecodeLegacyFilenames, names in
+	// Windows-1251 are transcoded to UTF-8 first.
+	EscapeFilename(filename string) string
+	// IsExtensionOnlyFilename reports whether the name is just a supported extension
+	// (".docx", "..docx") rather than a dotfile such as ".env". Such names have an
+	// empty GetFilenameWithoutExtension, so callers should supply a default title.
+	IsExtensionOnlyFilename(filename string) bool
+	IsExtensionSupported(fileExt string) bool
+	IsExtensionEditable(fileExt string) bool
+	IsExtensionLossEditable(fileExt string) bool
+	IsExtensionViewOnly(fileExt string) bool
+	IsExtensionOOXMLConvertable(fileExt string) bool
+	GetFileType(fileExt string) (string, error)
+	// GetFileTypeResolved is GetFileType that also returns the extension the
+	// file has once opened: the OOXML target for convertable formats (doc ->
+	// docx) and the normalized extension otherwise.
+	GetFileTypeResolved(fileExt string) (docType string, effectiveExt string, err error)
+	GetFileExt(filename string) string
+	// GetFilenameWithoutExtension strips the extension. Dotfiles such as ".env"
+	// are returned unchanged, extension-only names yield an empty string.
+	GetFilenameWithoutExtension(filename string) string
+	// SupportedExtensionSet returns a snapshot of every supported extension.
+	// The map is a copy: mutating it does not affect the utility, and callers
+	// should treat it as read-only to keep it consistent with the utility.
+	SupportedExtensionSet() map[string]struct{}
+	// ExtensionMatchesType reports whether the filename resolves to the declared document type.
+	ExtensionMatchesType(filename, declaredType string) (bool, error)
+	// ResolveEditorFileTypes returns the editor config documentType and fileType for a filename.
+	ResolveEditorFileTypes(filename string) (documentType, fileType string, err error)
+	// ResolveFileTypes classifies many filenames in one pass. Unsupported files
+	// are reported with CapabilityUnsupported and an empty document type.
+	ResolveFileTypes(filenames []string) []ResolvedFileType
+	// Describe returns every derived property of an extension in a single lookup.
+	Describe(fileExt string) (ExtensionInfo, error)
+	// SameDocumentType reports whether both extensions resolve to the same document type.
+	SameDocumentType(extA, extB string) (bool, error)
+	// SafeTempFilename derives a unique local filename from an untrusted name,
+	// keeping a supported extension so that the file type can still be detected.
+	// Unsupported extensions are dropped.
+	SafeTempFilename(originalName string) string
+	// Download fetches a file starting at offset, so an interrupted download can
+	// be resumed. A Range header is sent for non-zero offsets; when the server
+	// ignores it and sends the whole file, the bytes before offset are skipped.
+	Download(ctx context.Context, url string, offset int64) (io.ReadCloser, error)
+	// VerifyOOXMLIntegrity detects truncated OOXML files, returning ErrCorruptDocument.
+	VerifyOOXMLIntegrity(ctx context.Context, url string) error
+	// OOXMLConvertableExtensions returns a sorted snapshot of the extensions
+	// converted to OOXML when they are opened for editing.
+	OOXMLConvertableExtensions() []string
+	IsOpenedViaConversion(fileExt string) bool
+	// EditorFileTypeAfterConversion returns the extension the editor receives:
+	// the OOXML target for formats opened via conversion, ext otherwise.
+	EditorFileTypeAfterConversion(ext string) (string, error)
+	// EditableTargetForViewOnly returns the outputtype an editable copy of a
+	// view-only file is converted to, or ErrNoEditableTarget.
+	EditableTargetForViewOnly(ext string) (string, error)
+	// IsFillable reports whether the extension is opened for form filling: oform,
+	// and pdf when Options.PDFForms is set.
+	IsFillable(fileExt string) bool
+	// ValidateUploadedFile checks the first bytes of an upload against the claimed
+	// extension, returning ErrFileContentMismatch when they disagree.
+	ValidateUploadedFile(ext string, head []byte) error
+	// DisambiguateText samples a txt file and returns cell for delimiter
+	// separated data such as csv-like exports, word otherwise.
+	DisambiguateText(ctx context.Context, url string) (string, error)
+	// ExtensionsByType groups every supported extension by document type, sorted.
+	ExtensionsByType() map[string][]string
+	// ExtensionSupportDiff compares the supported extensions with another
+	// extension to document type set, such as a WOPI discovery result.
+	// Both results are sorted.
+	ExtensionSupportDiff(other map[string]string) (onlyInThis, onlyInOther []string)
+	// GetFileExtFromResponse prefers the filename of the Content-Disposition header
+	// (quoted, unquoted or RFC 5987 encoded) over the request url path.
+	GetFileExtFromResponse(resp *http.Response) string
+	// DetectExtension issues a HEAD request and resolves the extension from its response.
+	DetectExtension(ctx context.Context, url string) (string, error)
+}
+
+// ExtensionInfo groups the metadata derived from an extension.
+type ExtensionInfo struct {
+	Extension    string     `json:"extension"`
+	DocumentType string     `json:"documentType"`
+	Capability   Capability `json:"capability"`
+	MimeType     string     `json:"mimeType"`
+	// Editable is true when the file can be edited, natively or after conversion.
+	Editable bool `json:"editable"`
+	// ConversionTarget is the OOXML extension the file is converted to before
+	// editing, empty when no conversion is needed.
+	ConversionTarget string `json:"conversionTarget,omitempty"`
+}
+
+// ResolvedFileType is the classification of a single file returned by ResolveFileTypes.
+type ResolvedFileType struct {
+	Filename     string     `json:"filename"`
+	Extension    string     `json:"extension"`
+	DocumentType string     `json:"documentType,omitempty"`
+	Capability   Capability `json:"capability"`
+	// EffectiveExtension is the extension the editor receives, the OOXML target
+	// for convertable formats and Extension otherwise.
+	EffectiveExtension string `json:"effectiveExtension,omitempty"`
+}
+
+type fileUtility struct {
+	options   Options
+	index     map[string]extensionEntry
+	supported map[string]struct{}
+	// rejected holds extensions whose document type override could not be
+	// normalized. Their built-in entry, if any, is kept.
+	rejected map[string]error
+	// source resolves extensions missing from the index when a custom ExtensionSource is used.
+	source ExtensionSource
+}
+
+func NewOnlyofficeFileUtility(opts ...Option) OnlyofficeFileUtility {
+	return newFileUtility(newOptions(opts...))
+}
+
+func newFileUtility(options Options) fileUtility {
+	index := buildExtensionIndex()
+	if options.ExtensionSource != nil {
+		index = buildSourceIndex(options.ExtensionSource)
+	}
+
+	rejected := make(map[string]error)
+	for ext, value := range options.DocumentTypeOverrides {
+		ext = normalizeExtension(ext)
+		docType, err := NormalizeDocumentType(value)
+		if err != nil {
+			rejected[ext] = fmt.Errorf("%w: override %q for %q", ErrUnknownDocumentType, value, ext)
+			continue
+		}
+
+		entry, ok := index[ext]
+		if !ok && options.ExtensionSource != nil {
+			_, entry.capability, ok = options.ExtensionSource.Lookup(ext)
+		}
+
+		if !ok || entry.capability == CapabilityUnsupported {
+			entry.capability = CapabilityViewOnly
+		}
+
+		entry.docType = docType
+		index[ext] = entry
+	}
+
+	if options.BlockMacroExtensions {
+		for ext := range _OnlyofficeMacroEnabledExtensions {
+			delete(index, ext)
+		}
+	}
+
+	supported := make(map[string]struct{}, len(index))
+	for ext := range index {
+		supported[ext] = struct{}{}
+	}
+
+	return fileUtility{
+		options:   options,
+		index:     index,
+		supported: supported,
+		rejected:  rejected,
+		source:    options.ExtensionSource,
+	}
+}
+
+// defaultFileUtility is shared by helpers that classify files without a configured utility.
+var defaultFileUtility = sync.OnceValue(func() OnlyofficeFileUtility {
+	return NewOnlyofficeFileUtility()
+})
+
+func (u fileUtility) lookup(fileExt string) (extensionEntry, bool) {
+	ext := normalizeExtension(fileExt)
+	if entry, ok := u.index[ext]; ok || u.source == nil {
+		return entry, ok
+	}
+
+	if u.options.BlockMacroExtensions && IsMacroEnabledExtension(ext) {
+		return extensionEntry{}, false
+	}
+
+	docType, capability, ok := u.source.Lookup(ext)
+	if !ok || capability == CapabilityUnsupported {
+		return extensionEntry{}, false
+	}
+
+	return extensionEntry{docType: docType, capability: capability}, true
+}
+
+func (u fileUtility) ValidateFileSize(ctx context.Context, limit int64, url string) error {
+	return u.validateFileSize(ctx, limit, url, HTTPPurposeValidation, HTTPPurposeDownload)
+}
+
+// validateFileSize issues the HEAD request with the client for headPurpose
+// and the optional decompressed size download with the one for getPurpose.
+func (u fileUtility) validateFileSize(ctx context.Context, limit int64, url string, headPurpose, getPurpose HTTPPurpose) error {
+	if u.isTrustedURL(url) {
+		return nil
+	}
+
+	req, err := u.options.newRequest(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := u.options.do(headPurpose, req)
+	if err != nil {
+		return err
+	}
+	// Only the headers are needed. Options.do already released the
+	// ConcurrencyLimiter slot of this HEAD request; closing the body only
+	// returns the connection to the pool.
+	resp.Body.Close()
+
+	length := u.contentLength(resp.Header)
+	if isContentEncoded(resp.Header) {
+		if uncompressed := resp.Header.Get("X-Uncompressed-Length"); uncompressed != "" {
+			length = uncompressed
+		} else if u.options.EnforceDecompressedSize {
+			return u.validateDecompressedSize(ctx, limit, url, getPurpose)
+		}
+	}
+
+	if val, err := strconv.ParseInt(length, 10, 0); val > limit || err != nil {
+		return ErrInvalidContentLength
+	}
+
+	return nil
+}
+
+func (u fileUtility) isTrustedURL(rawURL string) bool {
+	if len(u.options.TrustedHosts) == 0 {
+		return false
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+
+	return isHostAllowed(parsed.Hostname(), u.options.TrustedHosts)
+}
+
+// validateDecompressedSize streams the gzip or deflate encoded body and counts
+// the decompressed bytes, stopping as soon as the limit is exceeded. Other
+// encodings cannot be measured and report ErrUnsupportedContentEncoding.
+func (u fileUtility) validateDecompressedSize(ctx context.Context, limit int64, url string, purpose HTTPPurpose) error {
+	req, err := u.options.newRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+
+	resp, err := u.options.do(purpose, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := decodeContent(resp.Header.Get("Content-Encoding"), resp.Body)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	size, err := io.Copy(io.Discard, io.LimitReader(body, limit+1))
+	if err != nil {
+		return err
+	}
+
+	if size > limit {
+		return ErrInvalidContentLength
+	}
+
+	return nil
+}
+
+// decodeContent wraps body with a decoder for encoding. Deflate is accepted
+// both zlib wrapped, as the HTTP specification requires, and raw, as sent by
+// some servers.
+func decodeContent(encoding string, body io.Reader) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return io.NopCloser(body), nil
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+
+		return reader, nil
+	case "deflate":
+		buffered := bufio.NewReader(body)
+		if header, err := buffered.Peek(2); err == nil && isZlibHeader(header) {
+			return zlib.NewReader(buffered)
+		}
+
+		return flate.NewReader(buffered), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentEncoding, encoding)
+	}
+}
+
+func isZlibHeader(header []byte) bool {
+	return header[0]&0x0F == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0
+}
+
+func isContentEncoded(header http.Header) bool {
+	encoding := strings.TrimSpace(header.Get("Content-Encoding"))
+	return encoding != "" && !strings.EqualFold(encoding, "identity")
+}
+
+func (u fileUtility) ValidateFileSizeAuto(ctx context.Context, url string, ext string) error {
+	limit, err := u.sizeLimit(ext)
+	if err != nil {
+		return err
+	}
+
+	return u.ValidateFileSize(ctx, limit, url)
+}
+
+func (u fileUtility) ValidateCallbackFileSize(ctx context.Context, body CallbackBody, limit int64) error {
+	if body.Size != nil {
+		if *body.Size < 0 || *body.Size > limit {
+			return ErrInvalidContentLength
+		}
+
+		return nil
+	}
+
+	if err := validateCallbackDownloadURL(body.URL, u.options.CallbackHosts, u.options.RequireHTTPS); err != nil {
+		return err
+	}
+
+	if len(u.options.CallbackHosts) == 0 {
+		// Any host is accepted, so refuse names resolving to internal addresses.
+		return u.validateFileSize(ctx, limit, body.URL, HTTPPurposeCallback, HTTPPurposeCallback)
+	}
+
+	return u.ValidateFileSize(ctx, limit, body.URL)
+}
+
+func (u fileUtility) sizeLimit(ext string) (int64, error) {
+	docType, err := u.GetFileType(ext)
+	if err != nil {
+		return 0, err
+	}
+
+	if limit, ok := u.options.ExtensionSizeLimits[normalizeExtension(ext)]; ok {
+		return limit, nil
+	}
+
+	if limit, ok := u.options.DocumentTypeSizeLimits[docType]; ok {
+		return limit, nil
+	}
+
+	return u.options.DefaultFileSizeLimit, nil
+}
+
+func (u fileUtility) contentLength(header http.Header) string {
+	for _, name := range u.options.ContentLengthHeaders {
+		if val := header.Get(name); val != "" {
+			return val
+		}
+	}
+
+	return ""
+}
+
+func (u fileUtility) EscapeFilename(filename string) string {
+	if u.options.DecodeLegacyFilenames && isLikelyCP1251(filename) {
+		filename = decodeCP1251(filename)
+	}
+
+	f := stripWindowsPathPrefix(filename)
+	f = strings.ReplaceAll(f, "\\", ":")
+	f = strings.ReplaceAll(f, "/", ":")
+	if u.IsExtensionOnlyFilename(f) {
+		return "." + strings.TrimLeft(f, ".")
+	}
+
+	return f
+}
+
+// stripWindowsPathPrefix keeps only the final component of UNC, device and NT
+// namespace paths. Other names are returned unchanged.
+func stripWindowsPathPrefix(filename string) string {
+	normalized := strings.ReplaceAll(filename, "/", "\\")
+	if !strings.HasPrefix(normalized, "\\\\") && !strings.HasPrefix(normalized, "\\??\\") {
+		return filename
+	}
+
+	return normalized[strings.LastIndex(normalized, "\\")+1:]
+}
+
+func (u fileUtility) IsExtensionOnlyFilename(filename string) bool {
+	name := strings.TrimLeft(filename, ".")
+	return name != "" && len(name) < len(filename) && !strings.Contains(name, ".") && u.IsExtensionSupported(name)
+}
+
+func (u fileUtility) IsExtensionSupported(fileExt string) bool {
+	_, ok := u.lookup(fileExt)
+	return ok
+}
+
+func (u fileUtility) IsExtensionEditable(fileExt string) bool {
+	entry, ok := u.lookup(fileExt)
+	return ok && entry.capability == CapabilityEditable
+}
+
+func (u fileUtility) IsExtensionLossEditable(fileExt string) bool {
+	entry, ok := u.lookup(fileExt)
+	return ok && entry.capability == CapabilityLossEditable
+}
+
+func (u fileUtility) IsExtensionViewOnly(fileExt string) bool {
+	entry, ok := u.lookup(fileExt)
+	return ok && entry.capability == CapabilityViewOnly
+}
+
+func (u fileUtility) IsExtensionOOXMLConvertable(fileExt string) bool {
+	entry, ok := u.lookup(fileExt)
+	return ok && entry.capability == CapabilityOOXMLConvertable
+}
+
+func (u fileUtility) GetFileType(fileExt string) (string, error) {
+	entry, ok := u.lookup(fileExt)
+	if !ok {
+		if err, rejected := u.rejected[normalizeExtension(fileExt)]; rejected {
+			return "", err
+		}
+
+		return "", ErrOnlyofficeExtensionNotSupported
+	}
+
+	return entry.docType, nil
+}
+
+func (u fileUtility) GetFileTypeResolved(fileExt string) (string, string, error) {
+	docType, err := u.GetFileType(fileExt)
+	if err != nil {
+		return "", "", err
+	}
+
+	if u.IsExtensionOOXMLConvertable(fileExt) {
+		return docType, _OnlyofficeOOXMLTargets[docType], nil
+	}
+
+	return docType, normalizeExtension(fileExt), nil
+}
+
+func (u fileUtility) GetFileExt(filename string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+	if !u.options.LenientExtensionMatching || u.IsExtensionSupported(ext) {
+		return ext
+	}
+
+	if lenient, ok := u.lenientFileExt(filename); ok {
+		return lenient
+	}
+
+	return ext
+}
+
+// lenientFileExt scans the last few dot-separated segments of a filename for a
+// known extension, ignoring anything after its leading alphanumeric token.
+func (u fileUtility) lenientFileExt(filename string) (string, bool) {
+	segments := strings.Split(filepath.Base(filename), ".")
+	for i := len(segments) - 1; i > 0 && i >= len(segments)-_LenientExtensionSegments; i-- {
+		token := strings.ToLower(segments[i])
+		if end := strings.IndexFunc(token, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}); end >= 0 {
+			token = token[:end]
+		}
+
+		if u.IsExtensionSupported(token) {
+			return token, true
+		}
+	}
+
+	return "", false
+}
+
+func (u fileUtility) GetFileExtFromResponse(resp *http.Response) string {
+	if resp == nil {
+		return ""
+	}
+
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		if ext := u.GetFileExt(params["filename"]); ext != "" {
+			return ext
+		}
+	}
+
+	if resp.Request != nil && resp.Request.URL != nil {
+		return u.GetFileExt(resp.Request.URL.Path)
+	}
+
+	return ""
+}
+
+func (u fileUtility) DetectExtension(ctx context.Context, url string) (string, error) {
+	req, err := u.options.newRequest(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := u.options.do(HTTPPurposeValidation, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	return u.GetFileExtFromResponse(resp), nil
+}
+
+func (u fileUtility) GetFilenameWithoutExtension(filename string) string {
+	if u.IsExtensionOnlyFilename(filename) {
+		return ""
+	}
+
+	if strings.LastIndex(filename, ".") == 0 {
+		return filename
+	}
+
+	return strings.TrimSuffix(filename, filepath.Ext(filename))
+}
+
+func (u fileUtility) SupportedExtensionSet() map[string]struct{} {
+	snapshot := make(map[string]struct{}, len(u.supported))
+	for ext := range u.supported {
+		snapshot[ext] = struct{}{}
+	}
+
+	return snapshot
+}
+
+func (u fileUtility) ExtensionMatchesType(filename, declaredType string) (bool, error) {
+	expected, err := NormalizeDocumentType(declaredType)
+	if err != nil {
+		return false, err
+	}
+
+	docType, err := u.GetFileType(u.GetFileExt(filename))
+	if err != nil {
+		return false, err
+	}
+
+	return docType == expected, nil
+}
+
+func (u fileUtility) ResolveEditorFileTypes(filename string) (documentType, fileType string, err error) {
+	fileType = u.GetFileExt(filename)
+	documentType, err = u.GetFileType(fileType)
+	if err != nil {
+		return "", "", err
+	}
+
+	return documentType, fileType, nil
+}
+
+func (u fileUtility) ResolveFileTypes(filenames []string) []ResolvedFileType {
+	resolved := make([]ResolvedFileType, len(filenames))
+	for i, filename := range filenames {
+		ext := u.GetFileExt(filename)
+		resolved[i] = ResolvedFileType{Filename: filename, Extension: ext}
+
+		entry, ok := u.lookup(ext)
+		if !ok {
+			continue
+		}
+
+		resolved[i].DocumentType = entry.docType
+		resolved[i].Capability = entry.capability
+		resolved[i].EffectiveExtension = ext
+		if entry.capability == CapabilityOOXMLConvertable {
+			resolved[i].EffectiveExtension = _OnlyofficeOOXMLTargets[entry.docType]
+		}
+	}
+
+	return resolved
+}
+
+func (u fileUtility) Describe(fileExt string) (ExtensionInfo, error) {
+	ext := normalizeExtension(fileExt)
+	entry, ok := u.lookup(ext)
+	if !ok {
+		return ExtensionInfo{}, ErrOnlyofficeExtensionNotSupported
+	}
+
+	info := ExtensionInfo{
+		Extension:    ext,
+		DocumentType: entry.docType,
+		Capability:   entry.capability,
+		MimeType:     MimeType(ext),
+		Editable:     entry.capability != CapabilityViewOnly,
+	}
+
+	if entry.capability == CapabilityOOXMLConvertable {
+		info.ConversionTarget = _OnlyofficeOOXMLTargets[entry.docType]
+	}
+
+	return info, nil
+}
+
+func (u fileUtility) SameDocumentType(extA, extB string) (bool, error) {
+	typeA, err := u.GetFileType(extA)
+	if err != nil {
+		return false, err
+	}
+
+	typeB, err := u.GetFileType(extB)
+	if err != nil {
+		return false, err
+	}
+
+	return typeA == typeB, nil
+}
+
+func (u fileUtility) SafeTempFilename(originalName string) string {
+	escaped := u.EscapeFilename(originalName)
+	ext := u.GetFileExt(escaped)
+	if !u.IsExtensionSupported(ext) {
+		// An unsupported extension is untrusted input of any length: drop it.
+		ext = ""
+	}
+	base := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`<>:"|?*\/`, r) {
+			return '_'
+		}
+
+		return r
+	}, u.GetFilenameWithoutExtension(escaped))
+	base = strings.Trim(base, ". ")
+
+	if runes := []rune(base); len(runes) > _TempFilenameMaxLength {
+		base = string(runes[:_TempFilenameMaxLength])
+	}
+
+	if base == "" {
+		base = _TempFilenameFallback
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		suffix = []byte(strconv.FormatInt(time.Now().UnixNano(), 16))
+	}
+
+	name := base + "-" + hex.EncodeToString(suffix)
+	if ext != "" {
+		name += "." + ext
+	}
+
+	return name
+}
+
+func (u fileUtility) OOXMLConvertableExtensions() []string {
+	extensions := make([]string, 0, len(OnlyofficeOOXMLConvertableExtensions))
+	for ext, entry := range u.index {
+		if entry.capability == CapabilityOOXMLConvertable {
+			extensions = append(extensions, ext)
+		}
+	}
+
+	sort.Strings(extensions)
+	return extensions
+}
+
+func (u fileUtility) IsOpenedViaConversion(fileExt string) bool {
+	return u.IsExtensionOOXMLConvertable(fileExt)
+}
+
+func (u fileUtility) IsFillable(fileExt string) bool {
+	ext := normalizeExtension(fileExt)
+	if _, ok := u.lookup(ext); !ok {
+		return false
+	}
+
+	switch ext {
+	case "oform":
+		return true
+	case "pdf":
+		return u.options.PDFForms
+	default:
+		return false
+	}
+}
+
+func (u fileUtility) ExtensionsByType() map[string][]string {
+	grouped := make(map[string][]string)
+	for ext, entry := range u.index {
+		grouped[entry.docType] = append(grouped[entry.docType], ext)
+	}
+
+	for _, extensions := range grouped {
+		sort.Strings(extensions)
+	}
+
+	return grouped
+}
+
+func (u fileUtility) ExtensionSupportDiff(other map[string]string) (onlyInThis, onlyInOther []string) {
+	normalized := make(map[string]struct{}, len(other))
+	for ext := range other {
+		normalized[normalizeExtension(ext)] = struct{}{}
+	}
+
+	for ext := range u.index {
+		if _, ok := normalized[ext]; !ok {
+			onlyInThis = append(onlyInThis, ext)
+		}
+	}
+
+	for ext := range normalized {
+		if _, ok := u.lookup(ext); !ok {
+			onlyInOther = append(onlyInOther, ext)
+		}
+	}
+
+	sort.Strings(onlyInThis)
+	sort.Strings(onlyInOther)
+	return onlyInThis, onlyInOther
+}
@@ -0,0 +1,994 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGetFileType(t *testing.T) {
+	util := NewOnlyofficeFileUtility()
+	tests := []struct {
+		ext      string
+		expected string
+		err      error
+	}{
+		{"docx", OnlyofficeWordType, nil},
+		{".XLSX", OnlyofficeCellType, nil},
+		{"ppt", OnlyofficeSlideType, nil},
+		{"pdf", OnlyofficeWordType, nil},
+		{"exe", "", ErrOnlyofficeExtensionNotSupported},
+	}
+
+	for _, test := range tests {
+		docType, err := util.GetFileType(test.ext)
+		if docType != test.expected || !errors.Is(err, test.err) {
+			t.Errorf("GetFileType(%q) = %q, %v; expected %q, %v", test.ext, docType, err, test.expected, test.err)
+		}
+	}
+}
+
+func TestExtensionCapabilities(t *testing.T) {
+	util := NewOnlyofficeFileUtility()
+	if !util.IsExtensionEditable("docx") || util.IsExtensionEditable("doc") {
+		t.Error("unexpected editable classification")
+	}
+
+	if !util.IsExtensionLossEditable("odt") || !util.IsExtensionOOXMLConvertable("doc") || !util.IsExtensionViewOnly("pdf") {
+		t.Error("unexpected capability classification")
+	}
+
+	if util.IsExtensionSupported("exe") {
+		t.Error("expected exe to be unsupported")
+	}
+}
+
+func TestGetFileExt(t *testing.T) {
+	util := NewOnlyofficeFileUtility()
+	if ext := util.GetFileExt("Report.Final.DOCX"); ext != "docx" {
+		t.Errorf("expected docx, got %q", ext)
+	}
+
+	if name := util.GetFilenameWithoutExtension("Report.Final.docx"); name != "Report.Final" {
+		t.Errorf("expected Report.Final, got %q", name)
+	}
+}
+
+func TestValidateFileSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+	}))
+	defer server.Close()
+
+	util := NewOnlyofficeFileUtility()
+	if err := util.ValidateFileSize(context.Background(), 100, server.URL); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if err := util.ValidateFileSize(context.Background(), 99, server.URL); !errors.Is(err, ErrInvalidContentLength) {
+		t.Errorf("expected ErrInvalidContentLength, got %v", err)
+	}
+}
+
+func TestSupportedExtensionSet(t *testing.T) {
+	util := NewOnlyofficeFileUtility()
+	set := util.SupportedExtensionSet()
+	if len(set) != len(buildExtensionIndex()) {
+		t.Fatalf("expected %d extensions, got %d", len(buildExtensionIndex()), len(set))
+	}
+
+	for ext := range set {
+		if !util.IsExtensionSupported(ext) {
+			t.Errorf("expected %q to be supported", ext)
+		}
+	}
+
+	delete(set, "docx")
+	set["exe"] = struct{}{}
+
+	if !util.IsExtensionSupported("docx") || util.IsExtensionSupported("exe") {
+		t.Error("mutating the snapshot changed the utility")
+	}
+
+	fresh := util.SupportedExtensionSet()
+	if _, ok := fresh["docx"]; !ok {
+		t.Error("mutating the snapshot changed subsequent snapshots")
+	}
+
+	if _, ok := fresh["exe"]; ok {
+		t.Error("mutating the snapshot changed subsequent snapshots")
+	}
+}
+
+var benchmarkExtensions = []string{"docx", "xlsx", "pptx", "pdf", "doc", "exe", "odt", "csv"}
+
+func BenchmarkIsExtensionSupported(b *testing.B) {
+	util := NewOnlyofficeFileUtility()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = util.IsExtensionSupported(benchmarkExtensions[i%len(benchmarkExtensions)])
+	}
+}
+
+func BenchmarkSupportedExtensionSet(b *testing.B) {
+	set := NewOnlyofficeFileUtility().SupportedExtensionSet()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = set[benchmarkExtensions[i%len(benchmarkExtensions)]]
+	}
+}
+
+func TestBlockMacroExtensions(t *testing.T) {
+	macros := []string{"docm", "dotm", "xlsm", "xltm", "pptm", "potm", "ppsm"}
+	permissive := NewOnlyofficeFileUtility()
+	strict := NewOnlyofficeFileUtility(WithBlockMacroExtensions(true))
+
+	for _, ext := range macros {
+		if !IsMacroEnabledExtension(ext) || !IsMacroEnabledExtension("."+ext) {
+			t.Errorf("expected %q to be macro-enabled", ext)
+		}
+
+		if !permissive.IsExtensionSupported(ext) {
+			t.Errorf("expected %q to be supported by default", ext)
+		}
+
+		if strict.IsExtensionSupported(ext) {
+			t.Errorf("expected %q to be blocked", ext)
+		}
+
+		if _, err := strict.GetFileType(ext); !errors.Is(err, ErrOnlyofficeExtensionNotSupported) {
+			t.Errorf("expected %q to be rejected by GetFileType, got %v", ext, err)
+		}
+
+		if _, ok := strict.SupportedExtensionSet()[ext]; ok {
+			t.Errorf("expected %q to be excluded from the supported set", ext)
+		}
+	}
+
+	if IsMacroEnabledExtension("docx") || !strict.IsExtensionSupported("docx") {
+		t.Error("expected docx to remain supported")
+	}
+}
+
+func TestValidateFileSizeAlternateHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Original-Content-Length", "500")
+	}))
+	defer server.Close()
+
+	util := NewOnlyofficeFileUtility(WithContentLengthHeaders("X-Original-Content-Length", "Content-Length"))
+	if err := util.ValidateFileSize(context.Background(), 500, server.URL); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if err := util.ValidateFileSize(context.Background(), 499, server.URL); !errors.Is(err, ErrInvalidContentLength) {
+		t.Errorf("expected ErrInvalidContentLength, got %v", err)
+	}
+
+	if err := NewOnlyofficeFileUtility().ValidateFileSize(context.Background(), 500, server.URL); !errors.Is(err, ErrInvalidContentLength) {
+		t.Errorf("expected only Content-Length to be consulted by default, got %v", err)
+	}
+}
+
+func TestGetFileExtLenient(t *testing.T) {
+	strict := NewOnlyofficeFileUtility()
+	lenient := NewOnlyofficeFileUtility(WithLenientExtensionMatching(true))
+	tests := []struct {
+		filename string
+		strict   string
+		lenient  string
+	}{
+		{"report.docx (1)", "docx (1)", "docx"},
+		{"file.DOCX.bak", "bak", "docx"},
+		{"budget.xlsx", "xlsx", "xlsx"},
+		{"archive.tar.gz", "gz", "gz"},
+		{"notes", "", ""},
+	}
+
+	for _, test := range tests {
+		if ext := strict.GetFileExt(test.filename); ext != test.strict {
+			t.Errorf("strict GetFileExt(%q) = %q; expected %q", test.filename, ext, test.strict)
+		}
+
+		if ext := lenient.GetFileExt(test.filename); ext != test.lenient {
+			t.Errorf("lenient GetFileExt(%q) = %q; expected %q", test.filename, ext, test.lenient)
+		}
+	}
+}
+
+func TestValidateFileSizeAuto(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "300")
+	}))
+	defer server.Close()
+
+	util := NewOnlyofficeFileUtility(
+		WithDocumentTypeSizeLimits(map[string]int64{
+			OnlyofficeCellType:  500,
+			OnlyofficeSlideType: 200,
+		}),
+		WithDefaultFileSizeLimit(100),
+	)
+
+	tests := []struct {
+		ext string
+		err error
+	}{
+		{"xlsx", nil},
+		{"pptx", ErrInvalidContentLength},
+		{"docx", ErrInvalidContentLength},
+		{"exe", ErrOnlyofficeExtensionNotSupported},
+	}
+
+	for _, test := range tests {
+		if err := util.ValidateFileSizeAuto(context.Background(), server.URL, test.ext); !errors.Is(err, test.err) {
+			t.Errorf("ValidateFileSizeAuto(%q) = %v; expected %v", test.ext, err, test.err)
+		}
+	}
+}
+
+func TestDocumentTypeSizeLimitsNormalized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "300")
+	}))
+	defer server.Close()
+
+	options := []Option{
+		WithDocumentTypeSizeLimits(map[string]int64{
+			"Spreadsheet": 500,
+			"SLIDE":       200,
+			" document ":  400,
+			"archive":     1000,
+		}),
+		WithDefaultFileSizeLimit(100),
+	}
+
+	if limits := newOptions(options...).DocumentTypeSizeLimits; len(limits) != 3 {
+		t.Errorf("expected the unknown document type to be dropped, got %v", limits)
+	}
+
+	util := NewOnlyofficeFileUtility(options...)
+	for ext, expected := range map[string]error{"xlsx": nil, "docx": nil, "pptx": ErrInvalidContentLength} {
+		if err := util.ValidateFileSizeAuto(context.Background(), server.URL, ext); !errors.Is(err, expected) {
+			t.Errorf("ValidateFileSizeAuto(%q) = %v; expected %v", ext, err, expected)
+		}
+	}
+}
+
+func TestExtensionMatchesType(t *testing.T) {
+	util := NewOnlyofficeFileUtility()
+	tests := []struct {
+		filename     string
+		declaredType string
+		matches      bool
+		err          error
+	}{
+		{"report.docx", OnlyofficeWordType, true, nil},
+		{"budget.XLSX", "Spreadsheet", true, nil},
+		{"slides.pptx", OnlyofficeWordType, false, nil},
+		{"budget.xls", "presentation", false, nil},
+		{"setup.exe", OnlyofficeWordType, false, ErrOnlyofficeExtensionNotSupported},
+		{"report.docx", "image", false, ErrUnknownDocumentType},
+	}
+
+	for _, test := range tests {
+		matches, err := util.ExtensionMatchesType(test.filename, test.declaredType)
+		if matches != test.matches || !errors.Is(err, test.err) {
+			t.Errorf("ExtensionMatchesType(%q, %q) = %v, %v; expected %v, %v", test.filename, test.declaredType, matches, err, test.matches, test.err)
+		}
+	}
+}
+
+func TestExtensionOnlyFilenames(t *testing.T) {
+	util := NewOnlyofficeFileUtility()
+	tests := []struct {
+		filename      string
+		extensionOnly bool
+		escaped       string
+		withoutExt    string
+		ext           string
+	}{
+		{".docx", true, ".docx", "", "docx"},
+		{"..docx", true, ".docx", "", "docx"},
+		{".env", false, ".env", ".env", "env"},
+		{"report.docx", false, "report.docx", "report", "docx"},
+	}
+
+	for _, test := range tests {
+		if extensionOnly := util.IsExtensionOnlyFilename(test.filename); extensionOnly != test.extensionOnly {
+			t.Errorf("IsExtensionOnlyFilename(%q) = %v; expected %v", test.filename, extensionOnly, test.extensionOnly)
+		}
+
+		escaped := util.EscapeFilename(test.filename)
+		if escaped != test.escaped {
+			t.Errorf("EscapeFilename(%q) = %q; expected %q", test.filename, escaped, test.escaped)
+		}
+
+		if withoutExt := util.GetFilenameWithoutExtension(escaped); withoutExt != test.withoutExt {
+			t.Errorf("GetFilenameWithoutExtension(%q) = %q; expected %q", escaped, withoutExt, test.withoutExt)
+		}
+
+		if ext := util.GetFileExt(escaped); ext != test.ext {
+			t.Errorf("GetFileExt(%q) = %q; expected %q", escaped, ext, test.ext)
+		}
+	}
+}
+
+func TestResolveEditorFileTypes(t *testing.T) {
+	util := NewOnlyofficeFileUtility()
+	tests := []struct {
+		filename     string
+		documentType string
+		fileType     string
+		err          error
+	}{
+		{"Report.DOCX", OnlyofficeWordType, "docx", nil},
+		{"budget.2023.xls", OnlyofficeCellType, "xls", nil},
+		{"deck.odp", OnlyofficeSlideType, "odp", nil},
+		{"scan.pdf", OnlyofficeWordType, "pdf", nil},
+		{"setup.exe", "", "", ErrOnlyofficeExtensionNotSupported},
+	}
+
+	for _, test := range tests {
+		documentType, fileType, err := util.ResolveEditorFileTypes(test.filename)
+		if documentType != test.documentType || fileType != test.fileType || !errors.Is(err, test.err) {
+			t.Errorf("ResolveEditorFileTypes(%q) = %q, %q, %v; expected %q, %q, %v", test.filename, documentType, fileType, err, test.documentType, test.fileType, test.err)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	util := NewOnlyofficeFileUtility()
+	tests := []ExtensionInfo{
+		{
+			Extension:    "docx",
+			DocumentType: OnlyofficeWordType,
+			Capability:   CapabilityEditable,
+			MimeType:     "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			Editable:     true,
+		},
+		{
+			Extension:        "xls",
+			DocumentType:     OnlyofficeCellType,
+			Capability:       CapabilityOOXMLConvertable,
+			MimeType:         "application/vnd.ms-excel",
+			Editable:         true,
+			ConversionTarget: "xlsx",
+		},
+		{
+			Extension:    "odp",
+			DocumentType: OnlyofficeSlideType,
+			Capability:   CapabilityLossEditable,
+			MimeType:     "application/vnd.oasis.opendocument.presentation",
+			Editable:     true,
+		},
+		{
+			Extension:    "pdf",
+			DocumentType: OnlyofficeWordType,
+			Capability:   CapabilityViewOnly,
+			MimeType:     "application/pdf",
+		},
+	}
+
+	for _, expected := range tests {
+		info, err := util.Describe("." + strings.ToUpper(expected.Extension))
+		if err != nil {
+			t.Errorf("Describe(%q) returned %v", expected.Extension, err)
+			continue
+		}
+
+		if info != expected {
+			t.Errorf("Describe(%q) = %+v; expected %+v", expected.Extension, info, expected)
+		}
+	}
+
+	if _, err := util.Describe("exe"); !errors.Is(err, ErrOnlyofficeExtensionNotSupported) {
+		t.Errorf("expected ErrOnlyofficeExtensionNotSupported, got %v", err)
+	}
+}
+
+func TestValidateFileSizeGzip(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write(bytes.Repeat([]byte("a"), 1000))
+	writer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Length", strconv.Itoa(compressed.Len()))
+		if r.URL.Path == "/declared" {
+			w.Header().Set("X-Uncompressed-Length", "1000")
+		}
+
+		if r.Method == http.MethodGet {
+			w.Write(compressed.Bytes())
+		}
+	}))
+	defer server.Close()
+
+	limit := int64(500)
+	if int64(compressed.Len()) > limit {
+		t.Fatalf("compressed payload is too large for the test: %d", compressed.Len())
+	}
+
+	util := NewOnlyofficeFileUtility()
+	if err := util.ValidateFileSize(context.Background(), limit, server.URL+"/declared"); !errors.Is(err, ErrInvalidContentLength) {
+		t.Errorf("expected X-Uncompressed-Length to be enforced, got %v", err)
+	}
+
+	if err := util.ValidateFileSize(context.Background(), limit, server.URL+"/plain"); err != nil {
+		t.Errorf("expected the transfer size to be checked by default, got %v", err)
+	}
+
+	strict := NewOnlyofficeFileUtility(WithEnforceDecompressedSize(true))
+	if err := strict.ValidateFileSize(context.Background(), limit, server.URL+"/plain"); !errors.Is(err, ErrInvalidContentLength) {
+		t.Errorf("expected the decompressed size to be enforced, got %v", err)
+	}
+
+	if err := strict.ValidateFileSize(context.Background(), 1000, server.URL+"/plain"); err != nil {
+		t.Errorf("expected the decompressed size to fit the limit, got %v", err)
+	}
+}
+
+func TestValidateFileSizeContentEncodings(t *testing.T) {
+	payload := bytes.Repeat([]byte("a"), 1000)
+	encoded := map[string][]byte{}
+
+	var zlibbed bytes.Buffer
+	zw := zlib.NewWriter(&zlibbed)
+	zw.Write(payload)
+	zw.Close()
+	encoded["/zlib"] = zlibbed.Bytes()
+
+	var raw bytes.Buffer
+	fw, _ := flate.NewWriter(&raw, flate.DefaultCompression)
+	fw.Write(payload)
+	fw.Close()
+	encoded["/raw"] = raw.Bytes()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/br" {
+			w.Header().Set("Content-Encoding", "br")
+			w.Header().Set("Content-Length", "10")
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "deflate")
+		w.Header().Set("Content-Length", strconv.Itoa(len(encoded[r.URL.Path])))
+		if r.Method == http.MethodGet {
+			w.Write(encoded[r.URL.Path])
+		}
+	}))
+	defer server.Close()
+
+	strict := NewOnlyofficeFileUtility(WithEnforceDecompressedSize(true))
+	for _, path := range []string{"/zlib", "/raw"} {
+		if err := strict.ValidateFileSize(context.Background(), 500, server.URL+path); !errors.Is(err, ErrInvalidContentLength) {
+			t.Errorf("%s: expected the decompressed size to be enforced, got %v", path, err)
+		}
+
+		if err := strict.ValidateFileSize(context.Background(), 1000, server.URL+path); err != nil {
+			t.Errorf("%s: expected the decompressed size to fit the limit, got %v", path, err)
+		}
+	}
+
+	if err := strict.ValidateFileSize(context.Background(), 1000, server.URL+"/br"); !errors.Is(err, ErrUnsupportedContentEncoding) {
+		t.Errorf("expected ErrUnsupportedContentEncoding for br, got %v", err)
+	}
+}
+
+func TestSameDocumentType(t *testing.T) {
+	util := NewOnlyofficeFileUtility()
+	tests := []struct {
+		extA string
+		extB string
+		same bool
+		err  error
+	}{
+		{"doc", "docx", true, nil},
+		{"ods", "XLSX", true, nil},
+		{"docx", "pptx", false, nil},
+		{"docx", "exe", false, ErrOnlyofficeExtensionNotSupported},
+		{"bin", "docx", false, ErrOnlyofficeExtensionNotSupported},
+	}
+
+	for _, test := range tests {
+		same, err := util.SameDocumentType(test.extA, test.extB)
+		if same != test.same || !errors.Is(err, test.err) {
+			t.Errorf("SameDocumentType(%q, %q) = %v, %v; expected %v, %v", test.extA, test.extB, same, err, test.same, test.err)
+		}
+	}
+}
+
+func TestSafeTempFilename(t *testing.T) {
+	util := NewOnlyofficeFileUtility()
+	seen := make(map[string]struct{})
+	for i := 0; i < 100; i++ {
+		name := util.SafeTempFilename("Quarterly Report.docx")
+		if _, ok := seen[name]; ok {
+			t.Fatalf("expected unique names, got %q twice", name)
+		}
+		seen[name] = struct{}{}
+
+		if !strings.HasPrefix(name, "Quarterly Report-") || util.GetFileExt(name) != "docx" {
+			t.Errorf("unexpected temp filename %q", name)
+		}
+	}
+
+	tests := map[string]string{
+		"../../etc/passwd.xlsx":                 "xlsx",
+		`C:\Users\john\budget.XLSX`:             "xlsx",
+		strings.Repeat("a", 500) + ".pptx":      "pptx",
+		"report:\x00<draft>?.odt":               "odt",
+		".docx":                                 "docx",
+		"notes":                                 "",
+		"setup.exe":                             "",
+		"payload." + strings.Repeat("x", 10000): "",
+	}
+
+	for original, ext := range tests {
+		name := util.SafeTempFilename(original)
+		if strings.ContainsAny(name, "/\\:<>?\"|*\x00") {
+			t.Errorf("SafeTempFilename(%q) = %q contains unsafe characters", original, name)
+		}
+
+		if util.GetFileExt(name) != ext {
+			t.Errorf("SafeTempFilename(%q) = %q; expected extension %q", original, name, ext)
+		}
+
+		if len([]rune(name)) > _TempFilenameMaxLength+len(ext)+18 {
+			t.Errorf("SafeTempFilename(%q) = %q is too long", original, name)
+		}
+	}
+}
+
+func TestOOXMLConvertableExtensions(t *testing.T) {
+	util := NewOnlyofficeFileUtility()
+	extensions := util.OOXMLConvertableExtensions()
+	if len(extensions) != len(OnlyofficeOOXMLConvertableExtensions) {
+		t.Fatalf("expected %d extensions, got %d", len(OnlyofficeOOXMLConvertableExtensions), len(extensions))
+	}
+
+	if !sort.StringsAreSorted(extensions) {
+		t.Errorf("expected a sorted list, got %v", extensions)
+	}
+
+	for _, ext := range extensions {
+		if _, ok := OnlyofficeOOXMLConvertableExtensions[ext]; !ok {
+			t.Errorf("unexpected extension %q", ext)
+		}
+
+		if !util.IsOpenedViaConversion(ext) {
+			t.Errorf("expected %q to be opened via conversion", ext)
+		}
+	}
+
+	extensions[0] = "mutated"
+	if util.OOXMLConvertableExtensions()[0] == "mutated" {
+		t.Error("expected a snapshot")
+	}
+
+	for _, ext := range []string{"docx", "odt", "pdf", "exe"} {
+		if util.IsOpenedViaConversion(ext) {
+			t.Errorf("expected %q not to be opened via conversion", ext)
+		}
+	}
+}
+
+func TestDocumentTypeOverrides(t *testing.T) {
+	util := NewOnlyofficeFileUtility(WithDocumentTypeOverrides(map[string]string{
+		"txt":  "SPREADSHEET",
+		".RTF": "Word",
+		"key":  "Presentation",
+		"odg":  "drawing",
+		"docx": "wrod",
+	}))
+
+	tests := []struct {
+		ext     string
+		docType string
+		err     error
+	}{
+		{"txt", OnlyofficeCellType, nil},
+		{"rtf", OnlyofficeWordType, nil},
+		{"key", OnlyofficeSlideType, nil},
+		{"odg", "", ErrUnknownDocumentType},
+		{"docx", OnlyofficeWordType, nil},
+	}
+
+	for _, test := range tests {
+		docType, err := util.GetFileType(test.ext)
+		if docType != test.docType || !errors.Is(err, test.err) {
+			t.Errorf("GetFileType(%q) = %q, %v; expected %q, %v", test.ext, docType, err, test.docType, test.err)
+		}
+	}
+
+	if !util.IsExtensionEditable("docx") {
+		t.Error("expected an invalid override to keep the built-in docx entry")
+	}
+
+	if !util.IsExtensionLossEditable("txt") || !util.IsExtensionViewOnly("key") || util.IsExtensionSupported("odg") {
+		t.Error("unexpected capabilities for overridden extensions")
+	}
+}
+
+func TestExtensionsByType(t *testing.T) {
+	grouped := NewOnlyofficeFileUtility().ExtensionsByType()
+	if len(grouped) != 3 {
+		t.Errorf("expected word, cell and slide groups, got %v", grouped)
+	}
+
+	seen := make(map[string]int)
+	for docType, extensions := range grouped {
+		if !sort.StringsAreSorted(extensions) {
+			t.Errorf("expected %s extensions to be sorted, got %v", docType, extensions)
+		}
+
+		for _, ext := range extensions {
+			seen[ext]++
+		}
+	}
+
+	for _, extensions := range []map[string]string{
+		OnlyofficeEditableExtensions,
+		OnlyofficeLossEditableExtensions,
+		OnlyofficeOOXMLConvertableExtensions,
+		OnlyofficeViewOnlyExtensions,
+	} {
+		for ext, docType := range extensions {
+			if seen[ext] != 1 {
+				t.Errorf("expected %q to appear exactly once, got %d", ext, seen[ext])
+			}
+
+			if idx := sort.SearchStrings(grouped[docType], ext); idx >= len(grouped[docType]) || grouped[docType][idx] != ext {
+				t.Errorf("expected %q in the %s group", ext, docType)
+			}
+		}
+	}
+}
+
+func TestExtensionSupportDiff(t *testing.T) {
+	util := NewOnlyofficeFileUtility()
+	wopi := make(map[string]string)
+	for docType, extensions := range util.ExtensionsByType() {
+		for _, ext := range extensions {
+			wopi[ext] = docType
+		}
+	}
+
+	delete(wopi, "djvu")
+	delete(wopi, "xps")
+	wopi[".VSDX"] = "diagram"
+	wopi["one"] = "note"
+
+	onlyInThis, onlyInOther := util.ExtensionSupportDiff(wopi)
+	if strings.Join(onlyInThis, ",") != "djvu,xps" {
+		t.Errorf("expected djvu,xps only in this set, got %v", onlyInThis)
+	}
+
+	if strings.Join(onlyInOther, ",") != "one,vsdx" {
+		t.Errorf("expected one,vsdx only in the other set, got %v", onlyInOther)
+	}
+}
+
+func TestGetFileExtFromResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/quoted":
+			w.Header().Set("Content-Disposition", `attachment; filename="Quarterly report.DOCX"`)
+		case "/unquoted":
+			w.Header().Set("Content-Disposition", "attachment; filename=budget.xlsx")
+		case "/encoded":
+			w.Header().Set("Content-Disposition", `attachment; filename="fallback.bin"; filename*=UTF-8''%D0%BE%D1%82%D1%87%D1%91%D1%82.pptx`)
+		}
+	}))
+	defer server.Close()
+
+	util := NewOnlyofficeFileUtility()
+	tests := map[string]string{
+		"/quoted":          "docx",
+		"/unquoted":        "xlsx",
+		"/encoded":         "pptx",
+		"/download/1.odt":  "odt",
+		"/download/object": "",
+	}
+
+	for path, expected := range tests {
+		ext, err := util.DetectExtension(context.Background(), server.URL+path)
+		if err != nil || ext != expected {
+			t.Errorf("DetectExtension(%q) = %q, %v; expected %q", path, ext, err, expected)
+		}
+	}
+}
+
+func TestValidateCallbackFileSize(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Length", "300")
+	}))
+	defer server.Close()
+
+	util := NewOnlyofficeFileUtility(WithCallbackHosts("127.0.0.1"))
+	small, large := int64(100), int64(1000)
+	tests := []struct {
+		name  string
+		size  *int64
+		err   error
+		calls int
+	}{
+		{"declared within limit", &small, nil, 0},
+		{"declared over limit", &large, ErrInvalidContentLength, 0},
+		{"missing size falls back to HEAD", nil, ErrInvalidContentLength, 1},
+	}
+
+	for _, test := range tests {
+		calls = 0
+		body := CallbackBody{Key: "key", Status: CallbackStatusReadyForSave, URL: server.URL, Size: test.size}
+		if err := util.ValidateCallbackFileSize(context.Background(), body, 200); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.err, err)
+		}
+
+		if calls != test.calls {
+			t.Errorf("%s: expected %d requests, got %d", test.name, test.calls, calls)
+		}
+	}
+}
+
+func TestValidateCallbackFileSizeRejectsInternalURL(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Length", "100")
+	}))
+	defer server.Close()
+
+	for _, util := range []OnlyofficeFileUtility{
+		NewOnlyofficeFileUtility(),
+		NewOnlyofficeFileUtility(WithCallbackHosts("docs.example.com")),
+		NewOnlyofficeFileUtility(WithCallbackHosts("127.0.0.1"), WithRequireHTTPS(true)),
+	} {
+		body := CallbackBody{Key: "key", Status: CallbackStatusReadyForSave, URL: server.URL}
+		if err := util.ValidateCallbackFileSize(context.Background(), body, 200); !errors.Is(err, ErrInvalidCallbackURL) {
+			t.Errorf("expected ErrInvalidCallbackURL for %q, got %v", server.URL, err)
+		}
+	}
+
+	if calls != 0 {
+		t.Errorf("expected no request to a rejected url, got %d", calls)
+	}
+}
+
+func TestValidateFileSizeAutoExtensionLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "300")
+	}))
+	defer server.Close()
+
+	util := NewOnlyofficeFileUtility(
+		WithExtensionSizeLimits(map[string]int64{
+			"TXT":   100,
+			".pptx": 1000,
+		}),
+		WithDocumentTypeSizeLimits(map[string]int64{
+			OnlyofficeWordType:  500,
+			OnlyofficeSlideType: 200,
+		}),
+		WithDefaultFileSizeLimit(400),
+	)
+
+	tests := []struct {
+		ext string
+		err error
+	}{
+		{"txt", ErrInvalidContentLength},
+		{"docx", nil},
+		{"pptx", nil},
+		{"ppt", ErrInvalidContentLength},
+		{"xlsx", nil},
+	}
+
+	for _, test := range tests {
+		if err := util.ValidateFileSizeAuto(context.Background(), server.URL, test.ext); !errors.Is(err, test.err) {
+			t.Errorf("ValidateFileSizeAuto(%q) = %v; expected %v", test.ext, err, test.err)
+		}
+	}
+}
+
+func TestEscapeFilenameWindowsPaths(t *testing.T) {
+	util := NewOnlyofficeFileUtility()
+	tests := []struct {
+		filename string
+		escaped  string
+	}{
+		{`\\server\share\reports\q1.docx`, "q1.docx"},
+		{`//server/share/q1.xlsx`, "q1.xlsx"},
+		{`\\?\C:\Users\me\Documents\q1.pptx`, "q1.pptx"},
+		{`\\.\C:\q1.docx`, "q1.docx"},
+		{`\\?\UNC\server\share\q1.docx`, "q1.docx"},
+		{`\??\C:\q1.docx`, "q1.docx"},
+		{`\\server\share\.docx`, ".docx"},
+		{`reports\q1.docx`, "reports:q1.docx"},
+		{`C:\q1.docx`, "C::q1.docx"},
+	}
+
+	for _, test := range tests {
+		if escaped := util.EscapeFilename(test.filename); escaped != test.escaped {
+			t.Errorf("EscapeFilename(%q) = %q; expected %q", test.filename, escaped, test.escaped)
+		}
+	}
+}
+
+func TestGetFileTypeResolved(t *testing.T) {
+	util := NewOnlyofficeFileUtility()
+	tests := []struct {
+		ext          string
+		docType      string
+		effectiveExt string
+		err          error
+	}{
+		{"doc", OnlyofficeWordType, "docx", nil},
+		{".XLS", OnlyofficeCellType, "xlsx", nil},
+		{"odt", OnlyofficeWordType, "odt", nil},
+		{"docx", OnlyofficeWordType, "docx", nil},
+		{"exe", "", "", ErrOnlyofficeExtensionNotSupported},
+	}
+
+	for _, test := range tests {
+		docType, effectiveExt, err := util.GetFileTypeResolved(test.ext)
+		if docType != test.docType || effectiveExt != test.effectiveExt || !errors.Is(err, test.err) {
+			t.Errorf("GetFileTypeResolved(%q) = %q, %q, %v; expected %q, %q, %v",
+				test.ext, docType, effectiveExt, err, test.docType, test.effectiveExt, test.err)
+		}
+	}
+
+	if docType, _ := util.GetFileType("doc"); docType != OnlyofficeWordType {
+		t.Errorf("GetFileType(%q) = %q; expected %q", "doc", docType, OnlyofficeWordType)
+	}
+}
+
+var benchmarkFilenames = []string{"report.docx", "budget.XLSX", "slides.pptx", "scan.pdf", "legacy.doc", "setup.exe", "notes.odt", "data.csv"}
+
+func TestResolveFileTypes(t *testing.T) {
+	util := NewOnlyofficeFileUtility()
+	resolved := util.ResolveFileTypes(benchmarkFilenames)
+	if len(resolved) != len(benchmarkFilenames) {
+		t.Fatalf("expected %d results, got %d", len(benchmarkFilenames), len(resolved))
+	}
+
+	for i, filename := range benchmarkFilenames {
+		result := resolved[i]
+		ext := util.GetFileExt(filename)
+		if result.Filename != filename || result.Extension != ext {
+			t.Errorf("ResolveFileTypes(%q) = %+v; expected extension %q", filename, result, ext)
+		}
+
+		docType, effectiveExt, err := util.GetFileTypeResolved(ext)
+		if err != nil {
+			if result.Capability != CapabilityUnsupported || result.DocumentType != "" || result.EffectiveExtension != "" {
+				t.Errorf("ResolveFileTypes(%q) = %+v; expected an unsupported result", filename, result)
+			}
+
+			continue
+		}
+
+		info, _ := util.Describe(ext)
+		if result.DocumentType != docType || result.EffectiveExtension != effectiveExt || result.Capability != info.Capability {
+			t.Errorf("ResolveFileTypes(%q) = %+v; expected %q, %q, %v", filename, result, docType, effectiveExt, info.Capability)
+		}
+	}
+}
+
+func BenchmarkResolveFileTypes(b *testing.B) {
+	util := NewOnlyofficeFileUtility()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = util.ResolveFileTypes(benchmarkFilenames)
+	}
+}
+
+func TestValidateFileSizeTrustedHosts(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Length", "300")
+	}))
+	defer server.Close()
+
+	trusted := NewOnlyofficeFileUtility(WithTrustedHosts("127.0.0.1"))
+	if err := trusted.ValidateFileSize(context.Background(), 100, server.URL); err != nil {
+		t.Errorf("expected a trusted url to be accepted, got %v", err)
+	}
+
+	if calls != 0 {
+		t.Errorf("expected no request for a trusted host, got %d", calls)
+	}
+
+	untrusted := NewOnlyofficeFileUtility(WithTrustedHosts("storage.example.com"))
+	if err := untrusted.ValidateFileSize(context.Background(), 100, server.URL); !errors.Is(err, ErrInvalidContentLength) {
+		t.Errorf("expected ErrInvalidContentLength, got %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("expected 1 request for an untrusted host, got %d", calls)
+	}
+}
+
+func TestEscapeFilenameLegacyEncoding(t *testing.T) {
+	// "Отчёт.docx" encoded in Windows-1251.
+	cp1251 := "\xCE\xF2\xF7\xB8\xF2.docx"
+	util := NewOnlyofficeFileUtility(WithDecodeLegacyFilenames(true))
+	tests := []struct {
+		filename string
+		escaped  string
+	}{
+		{cp1251, "Отчёт.docx"},
+		{"Отчёт.docx", "Отчёт.docx"},
+		{"report.docx", "report.docx"},
+		{`C:\` + cp1251, "C::Отчёт.docx"},
+	}
+
+	for _, test := range tests {
+		if escaped := util.EscapeFilename(test.filename); escaped != test.escaped {
+			t.Errorf("EscapeFilename(%q) = %q; expected %q", test.filename, escaped, test.escaped)
+		}
+	}
+
+	if escaped := NewOnlyofficeFileUtility().EscapeFilename(cp1251); escaped != cp1251 {
+		t.Errorf("expected the name to be untouched without the option, got %q", escaped)
+	}
+
+	if punctuation := "\x93\x94\x95.docx"; isLikelyCP1251(punctuation) {
+		t.Errorf("expected %q not to be detected as CP1251", punctuation)
+	}
+}
+
+func TestIsFillable(t *testing.T) {
+	tests := []struct {
+		ext      string
+		pdfForms bool
+		expected bool
+	}{
+		{"oform", false, true},
+		{"OFORM", true, true},
+		{"docx", false, false},
+		{"docx", true, false},
+		{"pdf", false, false},
+		{"pdf", true, true},
+		{"exe", true, false},
+	}
+
+	for _, test := range tests {
+		util := NewOnlyofficeFileUtility(WithPDFForms(test.pdfForms))
+		if fillable := util.IsFillable(test.ext); fillable != test.expected {
+			t.Errorf("IsFillable(%q) with pdf forms %v = %v; expected %v", test.ext, test.pdfForms, fillable, test.expected)
+		}
+	}
+}
@@ -0,0 +1,426 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	OnlyofficeWordType  string = "word"
+	OnlyofficeCellType  string = "cell"
+	OnlyofficeSlideType string = "slide"
+)
+
+// Capability describes what the document server can do with an extension.
+type Capability int
+
+const (
+	CapabilityUnsupported Capability = iota
+	CapabilityEditable
+	CapabilityLossEditable
+	CapabilityOOXMLConvertable
+	CapabilityViewOnly
+)
+
+func (c Capability) String() string {
+	switch c {
+	case CapabilityEditable:
+		return "editable"
+	case CapabilityLossEditable:
+		return "loss-editable"
+	case CapabilityOOXMLConvertable:
+		return "ooxml-convertable"
+	case CapabilityViewOnly:
+		return "view-only"
+	default:
+		return "unsupported"
+	}
+}
+
+// OnlyofficeEditableExtensions are opened for editing natively.
+var OnlyofficeEditableExtensions map[string]string = map[string]string{
+	"docx":  OnlyofficeWordType,
+	"docxf": OnlyofficeWordType,
+	"xlsx":  OnlyofficeCellType,
+	"pptx":  OnlyofficeSlideType,
+}
+
+// OnlyofficeLossEditableExtensions are editable, but saving may lose formatting.
+var OnlyofficeLossEditableExtensions map[string]string = map[string]string{
+	"csv":  OnlyofficeCellType,
+	"epub": OnlyofficeWordType,
+	"fb2":  OnlyofficeWordType,
+	"html": OnlyofficeWordType,
+	"odp":  OnlyofficeSlideType,
+	"ods":  OnlyofficeCellType,
+	"odt":  OnlyofficeWordType,
+	"otp":  OnlyofficeSlideType,
+	"ots":  OnlyofficeCellType,
+	"ott":  OnlyofficeWordType,
+	"rtf":  OnlyofficeWordType,
+	"txt":  OnlyofficeWordType,
+}
+
+// OnlyofficeOOXMLConvertableExtensions have to be converted to OOXML before editing.
+var OnlyofficeOOXMLConvertableExtensions map[string]string = map[string]string{
+	"doc":   OnlyofficeWordType,
+	"docm":  OnlyofficeWordType,
+	"dot":   OnlyofficeWordType,
+	"dotm":  OnlyofficeWordType,
+	"dotx":  OnlyofficeWordType,
+	"htm":   OnlyofficeWordType,
+	"mht":   OnlyofficeWordType,
+	"mhtml": OnlyofficeWordType,
+	"stw":   OnlyofficeWordType,
+	"sxw":   OnlyofficeWordType,
+	"wps":   OnlyofficeWordType,
+	"wpt":   OnlyofficeWordType,
+	"et":    OnlyofficeCellType,
+	"ett":   OnlyofficeCellType,
+	"sxc":   OnlyofficeCellType,
+	"xls":   OnlyofficeCellType,
+	"xlsm":  OnlyofficeCellType,
+	"xlt":   OnlyofficeCellType,
+	"xltm":  OnlyofficeCellType,
+	"xltx":  OnlyofficeCellType,
+	"dps":   OnlyofficeSlideType,
+	"dpt":   OnlyofficeSlideType,
+	"pot":   OnlyofficeSlideType,
+	"potm":  OnlyofficeSlideType,
+	"potx":  OnlyofficeSlideType,
+	"pps":   OnlyofficeSlideType,
+	"ppsm":  OnlyofficeSlideType,
+	"ppsx":  OnlyofficeSlideType,
+	"ppt":   OnlyofficeSlideType,
+	"pptm":  OnlyofficeSlideType,
+	"sxi":   OnlyofficeSlideType,
+}
+
+// OnlyofficeViewOnlyExtensions can only be opened for viewing.
+var OnlyofficeViewOnlyExtensions map[string]string = map[string]string{
+	"djvu":  OnlyofficeWordType,
+	"oform": OnlyofficeWordType,
+	"oxps":  OnlyofficeWordType,
+	"pdf":   OnlyofficeWordType,
+	"xps":   OnlyofficeWordType,
+	"xlsb":  OnlyofficeCellType,
+}
+
+var _OnlyofficeMimeTypes = map[string]string{
+	"csv":   "text/csv",
+	"djvu":  "image/vnd.djvu",
+	"doc":   "application/msword",
+	"docm":  "application/vnd.ms-word.document.macroEnabled.12",
+	"docx":  "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"docxf": "application/vnd.openxmlformats-officedocument.wordprocessingml.document.docxf",
+	"dot":   "application/msword",
+	"dotm":  "application/vnd.ms-word.template.macroEnabled.12",
+	"dotx":  "application/vnd.openxmlformats-officedocument.wordprocessingml.template",
+	"epub":  "application/epub+zip",
+	"fb2":   "application/x-fictionbook+xml",
+	"htm":   "text/html",
+	"html":  "text/html",
+	"mht":   "message/rfc822",
+	"mhtml": "message/rfc822",
+	"odp":   "application/vnd.oasis.opendocument.presentation",
+	"ods":   "application/vnd.oasis.opendocument.spreadsheet",
+	"odt":   "application/vnd.oasis.opendocument.text",
+	"oform": "application/vnd.openxmlformats-officedocument.wordprocessingml.document.oform",
+	"otp":   "application/vnd.oasis.opendocument.presentation-template",
+	"ots":   "application/vnd.oasis.opendocument.spreadsheet-template",
+	"ott":   "application/vnd.oasis.opendocument.text-template",
+	"oxps":  "application/oxps",
+	"pdf":   "application/pdf",
+	"pot":   "application/vnd.ms-powerpoint",
+	"potm":  "application/vnd.ms-powerpoint.template.macroEnabled.12",
+	"potx":  "application/vnd.openxmlformats-officedocument.presentationml.template",
+	"pps":   "application/vnd.ms-powerpoint",
+	"ppsm":  "application/vnd.ms-powerpoint.slideshow.macroEnabled.12",
+	"ppsx":  "application/vnd.openxmlformats-officedocument.presentationml.slideshow",
+	"ppt":   "application/vnd.ms-powerpoint",
+	"pptm":  "application/vnd.ms-powerpoint.presentation.macroEnabled.12",
+	"pptx":  "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	"rtf":   "application/rtf",
+	"sxc":   "application/vnd.sun.xml.calc",
+	"sxi":   "application/vnd.sun.xml.impress",
+	"sxw":   "application/vnd.sun.xml.writer",
+	"stw":   "application/vnd.sun.xml.writer.template",
+	"txt":   "text/plain",
+	"xls":   "application/vnd.ms-excel",
+	"xlsb":  "application/vnd.ms-excel.sheet.binary.macroEnabled.12",
+	"xlsm":  "application/vnd.ms-excel.sheet.macroEnabled.12",
+	"xlsx":  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"xlt":   "application/vnd.ms-excel",
+	"xltm":  "application/vnd.ms-excel.template.macroEnabled.12",
+	"xltx":  "application/vnd.openxmlformats-officedocument.spreadsheetml.template",
+	"xps":   "application/vnd.ms-xpsdocument",
+}
+
+const _DefaultMimeType = "application/octet-stream"
+
+// MimeType returns the media type of an extension, falling back to application/octet-stream.
+func MimeType(ext string) string {
+	if mimeType, ok := _OnlyofficeMimeTypes[normalizeExtension(ext)]; ok {
+		return mimeType
+	}
+
+	return _DefaultMimeType
+}
+
+var _OnlyofficeOOXMLTargets = map[string]string{
+	OnlyofficeWordType:  "docx",
+	OnlyofficeCellType:  "xlsx",
+	OnlyofficeSlideType: "pptx",
+}
+
+var _OnlyofficeMacroEnabledExtensions = map[string]struct{}{
+	"docm": {},
+	"dotm": {},
+	"potm": {},
+	"ppsm": {},
+	"pptm": {},
+	"xlsm": {},
+	"xltm": {},
+}
+
+// IsMacroEnabledExtension reports whether the extension denotes a macro-enabled format.
+func IsMacroEnabledExtension(ext string) bool {
+	_, ok := _OnlyofficeMacroEnabledExtensions[normalizeExtension(ext)]
+	return ok
+}
+
+var _OnlyofficeOpenDocumentExtensions = map[string]struct{}{
+	"odp": {},
+	"ods": {},
+	"odt": {},
+	"otp": {},
+	"ots": {},
+	"ott": {},
+}
+
+// IsOpenDocumentExtension reports whether the extension denotes an OpenDocument (ODF) format.
+func IsOpenDocumentExtension(ext string) bool {
+	_, ok := _OnlyofficeOpenDocumentExtensions[normalizeExtension(ext)]
+	return ok
+}
+
+// _OnlyofficeTemplateBaseExtensions maps template extensions to the extension
+// of documents created from them.
+var _OnlyofficeTemplateBaseExtensions = map[string]string{
+	"dotm": "docm",
+	"dotx": "docx",
+	"dpt":  "dps",
+	"ett":  "et",
+	"otp":  "odp",
+	"ots":  "ods",
+	"ott":  "odt",
+	"potm": "pptm",
+	"potx": "pptx",
+	"stw":  "sxw",
+	"wpt":  "wps",
+	"xltm": "xlsm",
+	"xltx": "xlsx",
+}
+
+// IsTemplateExtension reports whether the extension denotes a template format.
+func IsTemplateExtension(ext string) bool {
+	_, ok := _OnlyofficeTemplateBaseExtensions[normalizeExtension(ext)]
+	return ok
+}
+
+// BaseExtensionForTemplate returns the extension of documents created from a
+// template (dotx -> docx). Other extensions are returned normalized.
+func BaseExtensionForTemplate(ext string) string {
+	ext = normalizeExtension(ext)
+	if base, ok := _OnlyofficeTemplateBaseExtensions[ext]; ok {
+		return base
+	}
+
+	return ext
+}
+
+// Icon keys returned by IconKey.
+const (
+	IconKeyWord    string = "word"
+	IconKeyCell    string = "cell"
+	IconKeySlide   string = "slide"
+	IconKeyPDF     string = "pdf"
+	IconKeyUnknown string = "unknown"
+)
+
+// _OnlyofficePDFIconExtensions are fixed-layout formats shown with the pdf icon.
+var _OnlyofficePDFIconExtensions = map[string]struct{}{
+	"oxps": {},
+	"pdf":  {},
+	"xps":  {},
+}
+
+// IconKey returns a stable icon identifier for an extension: word, cell or
+// slide by document type, pdf for fixed-layout view-only formats, and unknown
+// together with ErrOnlyofficeExtensionNotSupported for unsupported extensions.
+func IconKey(ext string) (string, error) {
+	util := defaultFileUtility()
+	docType, err := util.GetFileType(ext)
+	if err != nil {
+		return IconKeyUnknown, err
+	}
+
+	if _, ok := _OnlyofficePDFIconExtensions[normalizeExtension(ext)]; ok && util.IsExtensionViewOnly(ext) {
+		return IconKeyPDF, nil
+	}
+
+	switch docType {
+	case OnlyofficeCellType:
+		return IconKeyCell, nil
+	case OnlyofficeSlideType:
+		return IconKeySlide, nil
+	default:
+		return IconKeyWord, nil
+	}
+}
+
+type extensionEntry struct {
+	docType    string
+	capability Capability
+}
+
+const _LossEditableSaveWarning = "Some formatting may be lost when saving in the %s format."
+
+// SaveWarning returns a user-facing warning for loss-editable extensions, whose
+// formatting may not survive saving. Native and unsupported extensions have none.
+func SaveWarning(ext string) (string, bool) {
+	if !defaultFileUtility().IsExtensionLossEditable(ext) {
+		return "", false
+	}
+
+	return fmt.Sprintf(_LossEditableSaveWarning, strings.ToUpper(normalizeExtension(ext))), true
+}
+
+type capabilityMap struct {
+	extensions map[string]string
+	capability Capability
+}
+
+// capabilityMaps lists the built-in capability maps in lookup precedence order.
+func capabilityMaps() []capabilityMap {
+	return []capabilityMap{
+		{OnlyofficeEditableExtensions, CapabilityEditable},
+		{OnlyofficeLossEditableExtensions, CapabilityLossEditable},
+		{OnlyofficeOOXMLConvertableExtensions, CapabilityOOXMLConvertable},
+		{OnlyofficeViewOnlyExtensions, CapabilityViewOnly},
+	}
+}
+
+// ValidateNoExtensionOverlap reports extensions listed in more than one
+// capability map. Keys are compared normalized, so "DOCX" and ".docx" collide
+// with "docx". Call it after modifying the exported maps.
+func ValidateNoExtensionOverlap() error {
+	return validateNoExtensionOverlap(capabilityMaps())
+}
+
+// ExtensionOverlap returns the ValidateNoExtensionOverlap result for the
+// capability maps as they were when the first file utility was built. The check
+// runs once per process; report a non-nil result at startup.
+func ExtensionOverlap() error {
+	return checkExtensionOverlap()
+}
+
+var checkExtensionOverlap = newExtensionOverlapCheck(capabilityMaps)
+
+// newExtensionOverlapCheck returns a check of the maps returned by sources
+// that runs on the first call only.
+func newExtensionOverlapCheck(sources func() []capabilityMap) func() error {
+	return sync.OnceValue(func() error {
+		return validateNoExtensionOverlap(sources())
+	})
+}
+
+func validateNoExtensionOverlap(sources []capabilityMap) error {
+	seen := make(map[string][]string)
+	for _, source := range sources {
+		for ext := range source.extensions {
+			normalized := normalizeExtension(ext)
+			seen[normalized] = append(seen[normalized], source.capability.String())
+		}
+	}
+
+	var overlaps []string
+	for ext, capabilities := range seen {
+		if len(capabilities) > 1 {
+			sort.Strings(capabilities)
+			overlaps = append(overlaps, fmt.Sprintf("%s (%s)", ext, strings.Join(capabilities, ", ")))
+		}
+	}
+
+	if len(overlaps) == 0 {
+		return nil
+	}
+
+	sort.Strings(overlaps)
+	return fmt.Errorf("%w: %s", ErrExtensionOverlap, strings.Join(overlaps, "; "))
+}
+
+// buildExtensionIndex combines the capability maps into a single lookup table
+// so that every classification is a single map access.
+func buildExtensionIndex() map[string]extensionEntry {
+	// Record the overlap check for ExtensionOverlap before the first index is used.
+	_ = checkExtensionOverlap()
+
+	index := make(map[string]extensionEntry)
+	for _, source := range capabilityMaps() {
+		for ext, docType := range source.extensions {
+			if _, ok := index[ext]; ok {
+				continue
+			}
+
+			index[ext] = extensionEntry{docType: docType, capability: source.capability}
+		}
+	}
+
+	return index
+}
+
+var _OnlyofficeDocumentTypeAliases = map[string]string{
+	"word":         OnlyofficeWordType,
+	"text":         OnlyofficeWordType,
+	"document":     OnlyofficeWordType,
+	"cell":         OnlyofficeCellType,
+	"spreadsheet":  OnlyofficeCellType,
+	"sheet":        OnlyofficeCellType,
+	"slide":        OnlyofficeSlideType,
+	"presentation": OnlyofficeSlideType,
+}
+
+// NormalizeDocumentType maps a document type or one of its common aliases
+// ("Spreadsheet", "presentation", ...) to word, cell or slide.
+func NormalizeDocumentType(docType string) (string, error) {
+	if normalized, ok := _OnlyofficeDocumentTypeAliases[strings.ToLower(strings.TrimSpace(docType))]; ok {
+		return normalized, nil
+	}
+
+	return "", ErrUnknownDocumentType
+}
+
+func normalizeExtension(fileExt string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(fileExt), "."))
+}
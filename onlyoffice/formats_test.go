@@ -0,0 +1,158 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestIsOpenDocumentExtension(t *testing.T) {
+	for _, ext := range []string{"odt", "ods", "odp", "ott", "ots", "otp", ".ODT"} {
+		if !IsOpenDocumentExtension(ext) {
+			t.Errorf("expected %q to be an OpenDocument extension", ext)
+		}
+	}
+
+	for _, ext := range []string{"docx", "xlsx", "pptx", "rtf", "odg", ""} {
+		if IsOpenDocumentExtension(ext) {
+			t.Errorf("expected %q not to be an OpenDocument extension", ext)
+		}
+	}
+}
+
+func TestSaveWarning(t *testing.T) {
+	for _, ext := range []string{"rtf", "odt", ".CSV"} {
+		if message, ok := SaveWarning(ext); !ok || message == "" {
+			t.Errorf("SaveWarning(%q) = %q, %v; expected a warning", ext, message, ok)
+		}
+	}
+
+	if message, _ := SaveWarning("odt"); !strings.Contains(message, "ODT") {
+		t.Errorf("expected the warning to name the format, got %q", message)
+	}
+
+	for _, ext := range []string{"docx", "doc", "pdf", "exe"} {
+		if message, ok := SaveWarning(ext); ok || message != "" {
+			t.Errorf("SaveWarning(%q) = %q, %v; expected no warning", ext, message, ok)
+		}
+	}
+}
+
+func TestTemplateExtensions(t *testing.T) {
+	tests := map[string]string{
+		"dotx": "docx", "dotm": "docm", "xltx": "xlsx", "xltm": "xlsm", "potx": "pptx", "potm": "pptm",
+		"ott": "odt", "ots": "ods", "otp": "odp", "stw": "sxw", "ett": "et", "dpt": "dps", "wpt": "wps",
+	}
+
+	for ext, base := range tests {
+		if !IsTemplateExtension(ext) || !IsTemplateExtension("."+strings.ToUpper(ext)) {
+			t.Errorf("expected %q to be a template extension", ext)
+		}
+
+		if got := BaseExtensionForTemplate(ext); got != base {
+			t.Errorf("BaseExtensionForTemplate(%q) = %q; expected %q", ext, got, base)
+		}
+
+		if IsTemplateExtension(base) {
+			t.Errorf("expected %q not to be a template extension", base)
+		}
+	}
+
+	if got := BaseExtensionForTemplate(".DOCX"); got != "docx" {
+		t.Errorf("BaseExtensionForTemplate(%q) = %q; expected %q", ".DOCX", got, "docx")
+	}
+}
+
+func TestValidateNoExtensionOverlap(t *testing.T) {
+	if err := ValidateNoExtensionOverlap(); err != nil {
+		t.Fatalf("expected the built-in maps not to overlap, got %v", err)
+	}
+
+	sources := capabilityMaps()
+	for i, source := range sources {
+		sources[i].extensions = maps.Clone(source.extensions)
+		switch source.capability {
+		case CapabilityViewOnly:
+			sources[i].extensions["DOCX"] = OnlyofficeWordType
+		case CapabilityLossEditable:
+			sources[i].extensions["pdf"] = OnlyofficeWordType
+		}
+	}
+
+	err := validateNoExtensionOverlap(sources)
+	if !errors.Is(err, ErrExtensionOverlap) {
+		t.Fatalf("expected ErrExtensionOverlap, got %v", err)
+	}
+
+	for _, expected := range []string{"docx (editable, view-only)", "pdf (loss-editable, view-only)"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in %q", expected, err.Error())
+		}
+	}
+}
+
+func TestExtensionOverlapCheck(t *testing.T) {
+	NewOnlyofficeFileUtility()
+	if err := ExtensionOverlap(); err != nil {
+		t.Fatalf("expected the built-in maps not to overlap, got %v", err)
+	}
+
+	sources := capabilityMaps()
+	for i, source := range sources {
+		sources[i].extensions = maps.Clone(source.extensions)
+	}
+
+	check := newExtensionOverlapCheck(func() []capabilityMap { return sources })
+	sources[len(sources)-1].extensions["DOCX"] = OnlyofficeWordType
+	if err := check(); !errors.Is(err, ErrExtensionOverlap) {
+		t.Fatalf("expected ErrExtensionOverlap, got %v", err)
+	}
+
+	delete(sources[len(sources)-1].extensions, "DOCX")
+	if err := check(); !errors.Is(err, ErrExtensionOverlap) {
+		t.Errorf("expected the first result to be kept, got %v", err)
+	}
+}
+
+func TestIconKey(t *testing.T) {
+	tests := []struct {
+		ext string
+		key string
+		err error
+	}{
+		{"docx", IconKeyWord, nil},
+		{"odt", IconKeyWord, nil},
+		{"djvu", IconKeyWord, nil},
+		{".XLSX", IconKeyCell, nil},
+		{"xlsb", IconKeyCell, nil},
+		{"ppt", IconKeySlide, nil},
+		{"pdf", IconKeyPDF, nil},
+		{"xps", IconKeyPDF, nil},
+		{"exe", IconKeyUnknown, ErrOnlyofficeExtensionNotSupported},
+	}
+
+	for _, test := range tests {
+		if key, err := IconKey(test.ext); key != test.key || !errors.Is(err, test.err) {
+			t.Errorf("IconKey(%q) = %q, %v; expected %q, %v", test.ext, key, err, test.key, test.err)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Version is the adapters version reported in the default User-Agent.
+const Version = "1.0.0"
+
+const _DefaultUserAgent = "onlyoffice-integration-adapters/" + Version
+
+// newRequest builds an outbound request carrying the configured User-Agent.
+func (o Options) newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", o.UserAgent)
+	return req, nil
+}
+
+// HTTPPurpose selects the pooled client used for an outbound request.
+type HTTPPurpose int
+
+const (
+	// HTTPPurposeValidation covers quick metadata requests such as HEAD checks.
+	HTTPPurposeValidation HTTPPurpose = iota
+	// HTTPPurposeDownload covers requests that stream file contents.
+	HTTPPurposeDownload
+	// HTTPPurposeConversion covers Document Server conversion and command calls.
+	HTTPPurposeConversion
+	// HTTPPurposeCallback covers requests to callback urls not restricted by
+	// CallbackHosts. Its default client refuses internal addresses, see
+	// NewExternalHTTPClient, and the generic HTTPClient does not replace it.
+	HTTPPurposeCallback
+)
+
+const (
+	_ValidationHTTPTimeout = 10 * time.Second
+	_DownloadHTTPTimeout   = 5 * time.Minute
+	_ConversionHTTPTimeout = 2 * time.Minute
+	_DialTimeout           = 30 * time.Second
+)
+
+func newPooledClient(timeout time.Duration, maxIdlePerHost int) *http.Client {
+	return newPooledClientWith(http.DefaultTransport.(*http.Transport).Clone(), timeout, maxIdlePerHost)
+}
+
+func newPooledClientWith(transport *http.Transport, timeout time.Duration, maxIdlePerHost int) *http.Client {
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = maxIdlePerHost
+	transport.IdleConnTimeout = 90 * time.Second
+	transport.ResponseHeaderTimeout = timeout
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+var _DefaultHTTPClients = map[HTTPPurpose]func() *http.Client{
+	HTTPPurposeValidation: sync.OnceValue(func() *http.Client {
+		return newPooledClient(_ValidationHTTPTimeout, 16)
+	}),
+	HTTPPurposeDownload: sync.OnceValue(func() *http.Client {
+		return newPooledClient(_DownloadHTTPTimeout, 4)
+	}),
+	HTTPPurposeConversion: sync.OnceValue(func() *http.Client {
+		return newPooledClient(_ConversionHTTPTimeout, 8)
+	}),
+	HTTPPurposeCallback: sync.OnceValue(func() *http.Client {
+		return NewExternalHTTPClient(_ValidationHTTPTimeout)
+	}),
+}
+
+// NewExternalHTTPClient returns a client that refuses to connect to loopback,
+// private and link-local addresses. The check runs on every address actually
+// dialed, so host names resolving to internal addresses are refused as well,
+// including after a DNS change between validation and the request. Proxies
+// are not used, since they would hide the target address. Use it to fetch
+// callback download urls when the document server hosts are not known.
+func NewExternalHTTPClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout:   _DialTimeout,
+		KeepAlive: _DialTimeout,
+		Control:   rejectInternalAddress,
+	}).DialContext
+
+	return newPooledClientWith(transport, timeout, 4)
+}
+
+// rejectInternalAddress is a net.Dialer Control hook refusing internal addresses.
+func rejectInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+
+	if ip := net.ParseIP(host); ip == nil || isInternalIP(ip) {
+		return fmt.Errorf("%w: internal address %s", ErrInvalidCallbackURL, host)
+	}
+
+	return nil
+}
+
+// httpClientFor returns the client for purpose: a purpose-specific override,
+// then the generic HTTPClient, then the shared pooled default. Callback
+// requests skip the generic HTTPClient to keep the internal address guard.
+func (o Options) httpClientFor(purpose HTTPPurpose) *http.Client {
+	if client, ok := o.HTTPClients[purpose]; ok {
+		return client
+	}
+
+	if o.HTTPClient != nil && purpose != HTTPPurposeCallback {
+		return o.HTTPClient
+	}
+
+	if client, ok := _DefaultHTTPClients[purpose]; ok {
+		return client()
+	}
+
+	return _DefaultHTTPClients[HTTPPurposeValidation]()
+}
+
+// _ServerResponseMaxSize bounds the document server responses read into memory.
+const _ServerResponseMaxSize = 1 << 20
+
+// ServerError is a document server response carrying a non-zero error code or
+// an unexpected HTTP status. Code values are service specific. It matches
+// ErrServerError with errors.Is.
+type ServerError struct {
+	StatusCode int
+	Code       int
+}
+
+func (e *ServerError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("error code %d", e.Code)
+	}
+
+	return fmt.Sprintf("unexpected status %d", e.StatusCode)
+}
+
+func (e *ServerError) Unwrap() error {
+	return ErrServerError
+}
+
+// parseServerResponse decodes a conversion or command service response into
+// out. The error field is checked first since the services report failures
+// with a 200 status, and out is left untouched unless the response succeeded.
+func parseServerResponse(body []byte, httpStatus int, out interface{}) error {
+	var envelope struct {
+		Error int `json:"error"`
+	}
+
+	decodeErr := json.Unmarshal(body, &envelope)
+	if decodeErr == nil && envelope.Error != 0 {
+		return &ServerError{StatusCode: httpStatus, Code: envelope.Error}
+	}
+
+	if httpStatus != http.StatusOK {
+		return &ServerError{StatusCode: httpStatus}
+	}
+
+	if decodeErr != nil {
+		return decodeErr
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// ContentDisposition builds an attachment Content-Disposition header carrying an
+// ASCII fallback filename and the RFC 5987 encoded UTF-8 filename.
+func ContentDisposition(filename string) string {
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7E || r == '"' || r == '\\' {
+			return '_'
+		}
+
+		return r
+	}, filename)
+
+	return fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, fallback, encodeRFC5987(filename))
+}
+
+func encodeRFC5987(value string) string {
+	var builder strings.Builder
+	for _, b := range []byte(value) {
+		if isRFC5987AttrChar(b) {
+			builder.WriteByte(b)
+			continue
+		}
+
+		fmt.Fprintf(&builder, "%%%02X", b)
+	}
+
+	return builder.String()
+}
+
+func isRFC5987AttrChar(b byte) bool {
+	switch {
+	case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9':
+		return true
+	default:
+		return strings.IndexByte("!#$&+-.^_`|~", b) >= 0
+	}
+}
+
+func isHostAllowed(host string, allowedHosts []string) bool {
+	for _, allowed := range allowedHosts {
+		if strings.EqualFold(strings.TrimSpace(allowed), host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isInternalHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && isInternalIP(ip)
+}
+
+func isInternalIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast()
+}
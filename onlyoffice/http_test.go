@@ -0,0 +1,217 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUserAgent(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("User-Agent")
+		w.Header().Set("Content-Length", "1")
+	}))
+	defer server.Close()
+
+	if err := NewOnlyofficeFileUtility().ValidateFileSize(context.Background(), 1, server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	if received != "onlyoffice-integration-adapters/"+Version {
+		t.Errorf("expected the default User-Agent, got %q", received)
+	}
+
+	util := NewOnlyofficeFileUtility(WithUserAgent("acme-connector/2.1"))
+	if err := util.ValidateFileSize(context.Background(), 1, server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	if received != "acme-connector/2.1" {
+		t.Errorf("expected the overridden User-Agent, got %q", received)
+	}
+}
+
+func TestContentDisposition(t *testing.T) {
+	tests := map[string]string{
+		"report.docx":     `attachment; filename="report.docx"; filename*=UTF-8''report.docx`,
+		"Q1 budget.xlsx":  `attachment; filename="Q1 budget.xlsx"; filename*=UTF-8''Q1%20budget.xlsx`,
+		`say "hi".docx`:   `attachment; filename="say _hi_.docx"; filename*=UTF-8''say%20%22hi%22.docx`,
+		"отчёт.docx":      `attachment; filename="_____.docx"; filename*=UTF-8''%D0%BE%D1%82%D1%87%D1%91%D1%82.docx`,
+		"résumé 2023.pdf": `attachment; filename="r_sum_ 2023.pdf"; filename*=UTF-8''r%C3%A9sum%C3%A9%202023.pdf`,
+	}
+
+	for filename, expected := range tests {
+		if header := ContentDisposition(filename); header != expected {
+			t.Errorf("ContentDisposition(%q) = %s; expected %s", filename, header, expected)
+		}
+	}
+}
+
+func TestHTTPClientFor(t *testing.T) {
+	o := newOptions()
+	validation := o.httpClientFor(HTTPPurposeValidation)
+	download := o.httpClientFor(HTTPPurposeDownload)
+	conversion := o.httpClientFor(HTTPPurposeConversion)
+
+	if validation.Timeout == download.Timeout || validation.Timeout == conversion.Timeout || download.Timeout == conversion.Timeout {
+		t.Errorf("expected distinct timeouts, got %v, %v, %v", validation.Timeout, download.Timeout, conversion.Timeout)
+	}
+
+	if newOptions().httpClientFor(HTTPPurposeValidation) != validation {
+		t.Errorf("expected the validation client to be shared across options")
+	}
+
+	custom := &http.Client{}
+	o = newOptions(WithHTTPClientFor(HTTPPurposeDownload, custom))
+	if o.httpClientFor(HTTPPurposeDownload) != custom {
+		t.Errorf("expected the download override to be used")
+	}
+
+	if o.httpClientFor(HTTPPurposeValidation) != validation {
+		t.Errorf("expected the validation default to be kept")
+	}
+
+	o = newOptions(WithHTTPClient(custom))
+	if o.httpClientFor(HTTPPurposeConversion) != custom {
+		t.Errorf("expected HTTPClient to apply to every purpose")
+	}
+
+	if o.httpClientFor(HTTPPurposeCallback) == custom {
+		t.Errorf("expected HTTPClient not to replace the guarded callback client")
+	}
+}
+
+func TestExternalHTTPClient(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer server.Close()
+
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewExternalHTTPClient(time.Second)
+	for _, target := range []string{server.URL, "http://localhost:" + port} {
+		resp, err := client.Get(target)
+		if err == nil {
+			resp.Body.Close()
+		}
+
+		if !errors.Is(err, ErrInvalidCallbackURL) {
+			t.Errorf("expected %q to be refused, got %v", target, err)
+		}
+	}
+
+	if calls != 0 {
+		t.Errorf("expected no request to reach an internal address, got %d", calls)
+	}
+}
+
+func TestRejectInternalAddress(t *testing.T) {
+	for address, internal := range map[string]bool{
+		"127.0.0.1:80":       true,
+		"10.1.2.3:443":       true,
+		"192.168.0.10:8080":  true,
+		"169.254.169.254:80": true,
+		"[::1]:443":          true,
+		"[fd00::1]:443":      true,
+		"0.0.0.0:80":         true,
+		"93.184.216.34:443":  false,
+		"[2606:4700::1]:443": false,
+	} {
+		if err := rejectInternalAddress("tcp", address, nil); (err != nil) != internal {
+			t.Errorf("rejectInternalAddress(%q) = %v; expected internal %v", address, err, internal)
+		}
+	}
+}
+
+func TestHTTPClientForReusesConnections(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		conns int
+	)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1")
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			conns++
+			mu.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	util := NewOnlyofficeFileUtility()
+	for i := 0; i < 5; i++ {
+		if err := util.ValidateFileSize(context.Background(), 10, server.URL); err != nil {
+			t.Fatalf("ValidateFileSize: %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if conns != 1 {
+		t.Errorf("expected 1 connection, got %d", conns)
+	}
+}
+
+func TestParseServerResponse(t *testing.T) {
+	var result ConvertResponse
+	err := parseServerResponse([]byte(`{"error":-3,"fileUrl":"https://docs.example.com/a.docx"}`), http.StatusOK, &result)
+
+	var serverErr *ServerError
+	if !errors.As(err, &serverErr) || serverErr.Code != -3 || !errors.Is(err, ErrServerError) {
+		t.Fatalf("expected a ServerError with code -3, got %v", err)
+	}
+
+	if result.FileURL != "" {
+		t.Errorf("expected out to be untouched on error, got %+v", result)
+	}
+
+	if err := parseServerResponse([]byte(`{"endConvert":true,"fileUrl":"https://docs.example.com/a.docx","percent":100}`), http.StatusOK, &result); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !result.EndConvert || result.FileURL != "https://docs.example.com/a.docx" || result.Percent != 100 {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	err = parseServerResponse([]byte("Bad Gateway"), http.StatusBadGateway, &result)
+	if !errors.As(err, &serverErr) || serverErr.StatusCode != http.StatusBadGateway || serverErr.Code != 0 {
+		t.Errorf("expected a ServerError with status 502, got %v", err)
+	}
+
+	if err := parseServerResponse([]byte("<html>"), http.StatusOK, &result); err == nil || errors.Is(err, ErrServerError) {
+		t.Errorf("expected a decoding error, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// OnlyofficeJWTManager signs and verifies HS256 tokens exchanged with the document server.
+type OnlyofficeJWTManager interface {
+	// Sign always uses the primary secret.
+	Sign(payload interface{}) (string, error)
+	// Verify accepts tokens signed with the primary or any verification secret,
+	// checks the exp and nbf claims and decodes the payload into body.
+	Verify(jwtToken string, body interface{}) error
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+// jwtTimeClaims holds RFC 7519 NumericDate claims, which may carry fractions of a second.
+type jwtTimeClaims struct {
+	ExpiresAt *float64 `json:"exp,omitempty"`
+	NotBefore *float64 `json:"nbf,omitempty"`
+}
+
+type jwtManager struct {
+	keys [][]byte
+}
+
+// NewOnlyofficeJWTManager creates a manager signing with secret. Additional
+// verificationSecrets are only used by Verify, which allows rotating the
+// document server secret without rejecting tokens signed with the old one.
+func NewOnlyofficeJWTManager(secret string, verificationSecrets ...string) OnlyofficeJWTManager {
+	keys := [][]byte{[]byte(secret)}
+	for _, verificationSecret := range verificationSecrets {
+		if verificationSecret != "" {
+			keys = append(keys, []byte(verificationSecret))
+		}
+	}
+
+	return jwtManager{keys: keys}
+}
+
+func (j jwtManager) Sign(payload interface{}) (string, error) {
+	header, err := json.Marshal(jwtHeader{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(j.signature(j.keys[0], unsigned)), nil
+}
+
+func (j jwtManager) Verify(jwtToken string, body interface{}) error {
+	parts := strings.Split(jwtToken, ".")
+	if len(parts) != 3 {
+		return ErrInvalidToken
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	unsigned := parts[0] + "." + parts[1]
+	verified := false
+	for _, key := range j.keys {
+		if hmac.Equal(signature, j.signature(key, unsigned)) {
+			verified = true
+			break
+		}
+	}
+
+	if !verified {
+		return ErrInvalidToken
+	}
+
+	var timeClaims jwtTimeClaims
+	if err := decodeSegment(parts[1], &timeClaims); err != nil {
+		return ErrInvalidToken
+	}
+
+	now := float64(time.Now().UnixMilli()) / 1000
+	if timeClaims.ExpiresAt != nil && now >= *timeClaims.ExpiresAt {
+		return ErrTokenExpired
+	}
+
+	if timeClaims.NotBefore != nil && now < *timeClaims.NotBefore {
+		return ErrInvalidToken
+	}
+
+	if body == nil {
+		return nil
+	}
+
+	return decodeSegment(parts[1], body)
+}
+
+// SignConfigMap signs a config built as a raw map and returns a copy with the
+// token stored under the "token" key. An existing token key is not signed over.
+func SignConfigMap(cfg map[string]interface{}, jwt OnlyofficeJWTManager) (map[string]interface{}, error) {
+	signed := make(map[string]interface{}, len(cfg)+1)
+	for k, v := range cfg {
+		if k != "token" {
+			signed[k] = v
+		}
+	}
+
+	token, err := jwt.Sign(signed)
+	if err != nil {
+		return nil, err
+	}
+
+	signed["token"] = token
+	return signed, nil
+}
+
+func (j jwtManager) signature(key []byte, unsigned string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(segment string, out interface{}) error {
+	buf, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(buf, out)
+}
@@ -0,0 +1,160 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type testClaims struct {
+	Key       string `json:"key"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+func TestJWTSignVerify(t *testing.T) {
+	manager := NewOnlyofficeJWTManager("primary")
+	token, err := manager.Sign(testClaims{Key: "document"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var claims testClaims
+	if err := manager.Verify(token, &claims); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if claims.Key != "document" {
+		t.Errorf("expected key document, got %q", claims.Key)
+	}
+
+	if err := NewOnlyofficeJWTManager("other").Verify(token, &claims); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+
+	if err := manager.Verify("not.a.token", &claims); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	previous := NewOnlyofficeJWTManager("old")
+	rotated := NewOnlyofficeJWTManager("new", "old")
+
+	oldToken, err := previous.Sign(testClaims{Key: "document"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var claims testClaims
+	if err := rotated.Verify(oldToken, &claims); err != nil {
+		t.Errorf("expected a token signed with the secondary secret to verify, got %v", err)
+	}
+
+	newToken, err := rotated.Sign(testClaims{Key: "document"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewOnlyofficeJWTManager("new").Verify(newToken, &claims); err != nil {
+		t.Errorf("expected tokens to be signed with the primary secret, got %v", err)
+	}
+
+	if err := previous.Verify(newToken, &claims); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected the secondary secret not to be used for signing, got %v", err)
+	}
+}
+
+func TestJWTExpiry(t *testing.T) {
+	manager := NewOnlyofficeJWTManager("secret")
+	token, err := manager.Sign(testClaims{Key: "document", ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := manager.Verify(token, nil); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("expected ErrTokenExpired, got %v", err)
+	}
+}
+
+func TestJWTFractionalNumericDates(t *testing.T) {
+	manager := NewOnlyofficeJWTManager("secret")
+	now := float64(time.Now().Unix())
+	tests := []struct {
+		claims map[string]interface{}
+		err    error
+	}{
+		{map[string]interface{}{"exp": now + 60.5, "nbf": now - 60.25}, nil},
+		{map[string]interface{}{"exp": now - 60.5}, ErrTokenExpired},
+		{map[string]interface{}{"nbf": now + 60.5}, ErrInvalidToken},
+	}
+
+	for _, test := range tests {
+		token, err := manager.Sign(test.claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := manager.Verify(token, nil); !errors.Is(err, test.err) {
+			t.Errorf("Verify(%v) = %v; expected %v", test.claims, err, test.err)
+		}
+	}
+}
+
+func TestSignConfigMap(t *testing.T) {
+	manager := NewOnlyofficeJWTManager("secret")
+	cfg := map[string]interface{}{
+		"documentType": "word",
+		"document":     map[string]interface{}{"key": "document", "title": "a.docx"},
+		"token":        "stale",
+	}
+
+	signed, err := SignConfigMap(cfg, manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg["token"] != "stale" {
+		t.Errorf("expected the input map to be left untouched")
+	}
+
+	token, ok := signed["token"].(string)
+	if !ok {
+		t.Fatalf("expected a token key, got %v", signed["token"])
+	}
+
+	var claims map[string]interface{}
+	if err := manager.Verify(token, &claims); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, ok := claims["token"]; ok {
+		t.Errorf("expected the token not to be signed over")
+	}
+
+	if claims["documentType"] != "word" {
+		t.Errorf("expected documentType word, got %v", claims["documentType"])
+	}
+
+	document, _ := claims["document"].(map[string]interface{})
+	if document["key"] != "document" {
+		t.Errorf("expected document key, got %v", claims["document"])
+	}
+}
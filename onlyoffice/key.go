@@ -0,0 +1,133 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+const (
+	_DocumentKeyMaxLength     = 128
+	_DocumentKeyRotationLabel = "_r"
+)
+
+// ValidateDocumentKey checks a document key against the document server rules:
+// at most 128 characters from 0-9, a-z, A-Z and ".", "=", "_", "-".
+func ValidateDocumentKey(key string) error {
+	if key == "" || len(key) > _DocumentKeyMaxLength {
+		return ErrInvalidDocumentKey
+	}
+
+	if strings.IndexFunc(key, func(r rune) bool { return !isDocumentKeyRune(r) }) >= 0 {
+		return ErrInvalidDocumentKey
+	}
+
+	return nil
+}
+
+func isDocumentKeyRune(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
+		r == '.' || r == '=' || r == '_' || r == '-'
+}
+
+func sanitizeDocumentKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if isDocumentKeyRune(r) {
+			return r
+		}
+
+		return '-'
+	}, key)
+}
+
+// NormalizeDocumentKey brings a key to the form the document server accepts.
+// Surrounding whitespace is trimmed and a key that is then valid is returned
+// as is. Any other key is replaced with its sanitized prefix followed by a hash
+// of the whole key, so distinct keys stay distinct. Keys are case sensitive.
+func NormalizeDocumentKey(key string) string {
+	trimmed := strings.TrimSpace(key)
+	if trimmed == "" || ValidateDocumentKey(trimmed) == nil {
+		return trimmed
+	}
+
+	sum := sha256.Sum256([]byte(trimmed))
+	digest := hex.EncodeToString(sum[:16])
+	prefix := sanitizeDocumentKey(trimmed)
+	if len(prefix) > _DocumentKeyMaxLength-len(digest)-1 {
+		prefix = prefix[:_DocumentKeyMaxLength-len(digest)-1]
+	}
+
+	return prefix + "-" + digest
+}
+
+// ShouldReconvert reports whether a converted result cached under prevKey is
+// stale for a document now identified by curKey. Cache converted files by the
+// document key and call it before serving a cached result. Both keys are
+// compared after NormalizeDocumentKey, so a raw key and its normalized form
+// match, while any other difference requires a reconversion, as does a missing
+// prevKey.
+func ShouldReconvert(prevKey, curKey string) bool {
+	prev := NormalizeDocumentKey(prevKey)
+	return prev == "" || prev != NormalizeDocumentKey(curKey)
+}
+
+// RotateDocumentKey derives a new key from the current one so that the editor
+// reloads a document changed out of band. The result carries a rotation counter
+// ("key_r1", "key_r2", ...), stays within the document key charset and length,
+// and is the same for the same input.
+func RotateDocumentKey(currentKey string) string {
+	base := sanitizeDocumentKey(currentKey)
+
+	counter := 1
+	if i := strings.LastIndex(base, _DocumentKeyRotationLabel); i >= 0 {
+		if n, err := strconv.Atoi(base[i+len(_DocumentKeyRotationLabel):]); err == nil && n > 0 {
+			base, counter = base[:i], n+1
+		}
+	}
+
+	suffix := _DocumentKeyRotationLabel + strconv.Itoa(counter)
+	if len(base)+len(suffix) > _DocumentKeyMaxLength {
+		base = base[:_DocumentKeyMaxLength-len(suffix)]
+	}
+
+	return base + suffix
+}
+
+// randomDocumentKey generates a key for one-off requests such as uploads.
+func randomDocumentKey() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
+
+// FileVersionTag derives a version tag from file metadata. The tag is the same
+// for the same size, modification time and extension and changes when any of
+// them does. It is hex encoded, so it can be quoted into an ETag header or used
+// as a document key as is.
+func FileVersionTag(size int64, modifiedUnix int64, ext string) string {
+	sum := sha256.Sum256([]byte(strconv.FormatInt(size, 10) + ":" + strconv.FormatInt(modifiedUnix, 10) + ":" + normalizeExtension(ext)))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,136 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateDocumentKey(t *testing.T) {
+	for _, valid := range []string{"key", "Khirz6zTPdfd7-1700000000", "a.b=c_d", strings.Repeat("k", 128)} {
+		if err := ValidateDocumentKey(valid); err != nil {
+			t.Errorf("expected %q to be valid, got %v", valid, err)
+		}
+	}
+
+	for _, invalid := range []string{"", "key with spaces", "key/slash", "ключ", strings.Repeat("k", 129)} {
+		if err := ValidateDocumentKey(invalid); !errors.Is(err, ErrInvalidDocumentKey) {
+			t.Errorf("expected %q to be invalid, got %v", invalid, err)
+		}
+	}
+}
+
+func TestRotateDocumentKey(t *testing.T) {
+	if rotated := RotateDocumentKey("document"); rotated != "document_r1" {
+		t.Errorf("expected document_r1, got %q", rotated)
+	}
+
+	if RotateDocumentKey("document_r1") != RotateDocumentKey("document_r1") {
+		t.Error("expected rotation to be deterministic")
+	}
+
+	for _, initial := range []string{"document", "", "key with spaces/ünicode", strings.Repeat("k", 128)} {
+		seen := map[string]struct{}{initial: {}}
+		key := initial
+		for i := 0; i < 20; i++ {
+			key = RotateDocumentKey(key)
+			if err := ValidateDocumentKey(key); err != nil {
+				t.Fatalf("rotation %d of %q produced an invalid key %q: %v", i, initial, key, err)
+			}
+
+			if _, ok := seen[key]; ok {
+				t.Fatalf("rotation %d of %q repeated the key %q", i, initial, key)
+			}
+			seen[key] = struct{}{}
+		}
+	}
+}
+
+func TestFileVersionTag(t *testing.T) {
+	tag := FileVersionTag(1024, 1700000000, "docx")
+	if tag != FileVersionTag(1024, 1700000000, ".DOCX") {
+		t.Errorf("expected the tag to be stable")
+	}
+
+	if err := ValidateDocumentKey(tag); err != nil {
+		t.Errorf("expected %q to be a valid document key, got %v", tag, err)
+	}
+
+	if strings.ContainsAny(tag, "\" \t\r\n") {
+		t.Errorf("expected %q to be safe in an ETag header", tag)
+	}
+
+	for _, changed := range []string{
+		FileVersionTag(1025, 1700000000, "docx"),
+		FileVersionTag(1024, 1700000001, "docx"),
+		FileVersionTag(1024, 1700000000, "xlsx"),
+	} {
+		if changed == tag {
+			t.Errorf("expected %q to differ from %q", changed, tag)
+		}
+	}
+}
+
+func TestShouldReconvert(t *testing.T) {
+	tests := []struct {
+		prevKey   string
+		curKey    string
+		reconvert bool
+	}{
+		{"Khirz6zTPdfd7", "Khirz6zTPdfd7", false},
+		{" Khirz6zTPdfd7\n", "Khirz6zTPdfd7", false},
+		{"file 42/v1", "file-42-v1", true},
+		{"файл-v1", "ключ-v1", true},
+		{strings.Repeat("k", 130), strings.Repeat("k", 128), true},
+		{strings.Repeat("k", 130), NormalizeDocumentKey(strings.Repeat("k", 130)), false},
+		{"file 42/v1", NormalizeDocumentKey("file 42/v1"), false},
+		{strings.Repeat("k", 129) + "a", strings.Repeat("k", 129) + "b", true},
+		{"Khirz6zTPdfd7", "Khirz6zTPdfd8", true},
+		{"key", "KEY", true},
+		{"key", RotateDocumentKey("key"), true},
+		{"", "key", true},
+	}
+
+	for _, test := range tests {
+		if reconvert := ShouldReconvert(test.prevKey, test.curKey); reconvert != test.reconvert {
+			t.Errorf("ShouldReconvert(%q, %q) = %v; expected %v", test.prevKey, test.curKey, reconvert, test.reconvert)
+		}
+	}
+
+	if normalized := NormalizeDocumentKey(" Khirz6zTPdfd7\n"); normalized != "Khirz6zTPdfd7" {
+		t.Errorf("NormalizeDocumentKey kept a valid key as %q; expected it unchanged", normalized)
+	}
+
+	for _, pair := range [][2]string{
+		{"file 42/v1", "file-42-v1"},
+		{"файл-v1", "ключ-v1"},
+		{strings.Repeat("k", 129) + "a", strings.Repeat("k", 129) + "b"},
+	} {
+		a, b := NormalizeDocumentKey(pair[0]), NormalizeDocumentKey(pair[1])
+		if ValidateDocumentKey(a) != nil || ValidateDocumentKey(b) != nil {
+			t.Errorf("expected normalized keys %q and %q to be valid", a, b)
+		}
+
+		if a == b {
+			t.Errorf("NormalizeDocumentKey(%q) = NormalizeDocumentKey(%q) = %q; expected distinct keys", pair[0], pair[1], a)
+		}
+	}
+}
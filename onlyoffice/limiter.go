@@ -0,0 +1,95 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ConcurrencyLimiter bounds the number of outbound requests in flight. Share a
+// single limiter between file utilities, converters and command clients with
+// WithConcurrencyLimiter to keep the whole adapter within one budget, however
+// many operations run at the same time.
+type ConcurrencyLimiter struct {
+	slots chan struct{}
+}
+
+// NewConcurrencyLimiter creates a limiter allowing up to limit concurrent
+// requests. A limit below one is treated as one.
+func NewConcurrencyLimiter(limit int) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{slots: make(chan struct{}, max(1, limit))}
+}
+
+// acquire waits for a free slot or for ctx to be done. A nil limiter never blocks.
+func (l *ConcurrencyLimiter) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *ConcurrencyLimiter) release() {
+	if l != nil {
+		<-l.slots
+	}
+}
+
+// releasingBody gives the limiter slot back once the response body is closed,
+// so streamed downloads count against the budget until they are consumed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	limiter *ConcurrencyLimiter
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.limiter.release)
+	return err
+}
+
+// do sends req with the client for purpose once the configured limiter has a
+// free slot. The slot is held until the response body is closed, except for
+// HEAD responses which carry no body and release it right away.
+func (o Options) do(purpose HTTPPurpose, req *http.Request) (*http.Response, error) {
+	if err := o.ConcurrencyLimiter.acquire(req.Context()); err != nil {
+		return nil, err
+	}
+
+	resp, err := o.httpClientFor(purpose).Do(req)
+	if err != nil || req.Method == http.MethodHead {
+		o.ConcurrencyLimiter.release()
+		return resp, err
+	}
+
+	if o.ConcurrencyLimiter != nil {
+		resp.Body = &releasingBody{ReadCloser: resp.Body, limiter: o.ConcurrencyLimiter}
+	}
+
+	return resp, nil
+}
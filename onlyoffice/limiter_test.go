@@ -0,0 +1,170 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimiterSharedAcrossBulkCalls(t *testing.T) {
+	const limit = 3
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			previous := peak.Load()
+			if current <= previous || peak.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	limiter := NewConcurrencyLimiter(limit)
+	bulk := func(util OnlyofficeFileUtility) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := util.DetectExtension(context.Background(), server.URL+"/report.docx"); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bulk(NewOnlyofficeFileUtility(WithConcurrencyLimiter(limiter)))
+		}()
+	}
+	wg.Wait()
+
+	if peak.Load() > limit {
+		t.Errorf("observed %d concurrent requests; expected at most %d", peak.Load(), limit)
+	}
+}
+
+func TestConcurrencyLimiterHoldsSlotUntilBodyClosed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	util := NewOnlyofficeFileUtility(WithConcurrencyLimiter(NewConcurrencyLimiter(1)))
+	body, err := util.Download(context.Background(), server.URL, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := util.Download(ctx, server.URL, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the second download to wait for a slot, got %v", err)
+	}
+
+	body.Close()
+	body, err = util.Download(context.Background(), server.URL, 0)
+	if err != nil {
+		t.Fatalf("expected the slot to be released on close, got %v", err)
+	}
+	body.Close()
+}
+
+// TestConcurrencyLimiterNestedRequests runs every operation issuing several
+// requests with a single slot, which deadlocks if a slot is still held when
+// the next request starts.
+func TestConcurrencyLimiterNestedRequests(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write(bytes.Repeat([]byte("a"), 1000))
+	writer.Close()
+
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		if r.Method == http.MethodGet {
+			w.Write(compressed.Bytes())
+		}
+	}))
+	defer storage.Close()
+
+	commands := newMockCommandServer(t)
+	defer commands.Close()
+
+	calls := 0
+	conversions := newMockConvertServer(t, func(body map[string]interface{}) string {
+		calls++
+		if calls < 3 {
+			return `{"endConvert":false,"percent":50}`
+		}
+
+		return `{"endConvert":true,"fileType":"docx","fileUrl":"https://docs.example.com/output.docx","percent":100}`
+	})
+	defer conversions.Close()
+
+	limiter := WithConcurrencyLimiter(NewConcurrencyLimiter(1))
+	util := NewOnlyofficeFileUtility(limiter, WithEnforceDecompressedSize(true))
+	client, err := NewOnlyofficeCommandClient(commands.URL, limiter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conv := newTestConverter(t, conversions.URL, limiter)
+
+	operations := map[string]func(ctx context.Context) error{
+		"ValidateFileSize": func(ctx context.Context) error {
+			return util.ValidateFileSize(ctx, 1000, storage.URL)
+		},
+		"ValidateFileSizeAuto": func(ctx context.Context) error {
+			return util.ValidateFileSizeAuto(ctx, storage.URL, "docx")
+		},
+		"HealthCheck": func(ctx context.Context) error {
+			_, err := client.HealthCheck(ctx)
+			return err
+		},
+		"ConvertAndWait": func(ctx context.Context) error {
+			_, err := conv.ConvertAndWait(ctx, ConvertRequest{FileType: "doc", Key: "key", OutputType: "docx", URL: "https://storage.example.com/file.doc"})
+			return err
+		},
+	}
+
+	for name, operation := range operations {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		if err := operation(ctx); err != nil {
+			t.Errorf("%s with a single slot returned %v", name, err)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,107 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NormalizeLocale converts a BCP-47 tag to its canonical casing, accepting
+// underscores as separators ("en_us" becomes "en-US", "zh-hans-cn" becomes "zh-Hans-CN").
+func NormalizeLocale(locale string) (string, error) {
+	subtags := strings.FieldsFunc(strings.TrimSpace(locale), func(r rune) bool {
+		return r == '-' || r == '_'
+	})
+
+	if len(subtags) == 0 {
+		return "", ErrInvalidLocale
+	}
+
+	for i, subtag := range subtags {
+		if len(subtag) > 8 || strings.IndexFunc(subtag, func(r rune) bool {
+			return r > unicode.MaxASCII || (!unicode.IsLetter(r) && !unicode.IsDigit(r))
+		}) >= 0 {
+			return "", ErrInvalidLocale
+		}
+
+		switch {
+		case i == 0:
+			if len(subtag) < 2 || len(subtag) > 3 || !isLetters(subtag) {
+				return "", ErrInvalidLocale
+			}
+			subtags[i] = strings.ToLower(subtag)
+		case len(subtag) == 4 && isLetters(subtag):
+			subtags[i] = strings.ToUpper(subtag[:1]) + strings.ToLower(subtag[1:])
+		case len(subtag) == 2 && isLetters(subtag), len(subtag) == 3 && !isLetters(subtag):
+			subtags[i] = strings.ToUpper(subtag)
+		default:
+			subtags[i] = strings.ToLower(subtag)
+		}
+	}
+
+	return strings.Join(subtags, "-"), nil
+}
+
+func isLetters(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) }) < 0
+}
+
+var _RTLLanguages = map[string]struct{}{
+	"ar":  {},
+	"ckb": {},
+	"dv":  {},
+	"fa":  {},
+	"he":  {},
+	"iw":  {},
+	"ps":  {},
+	"sd":  {},
+	"ug":  {},
+	"ur":  {},
+	"yi":  {},
+}
+
+var _RTLScripts = map[string]struct{}{
+	"Arab": {},
+	"Hebr": {},
+	"Syrc": {},
+	"Thaa": {},
+}
+
+// IsRTLLocale reports whether a locale is written right-to-left, either by its
+// language ("ar", "he-IL", "fa") or by an explicit script subtag ("az-Arab").
+func IsRTLLocale(locale string) bool {
+	normalized, err := NormalizeLocale(locale)
+	if err != nil {
+		return false
+	}
+
+	subtags := strings.Split(normalized, "-")
+	if _, ok := _RTLLanguages[subtags[0]]; ok {
+		return true
+	}
+
+	for _, subtag := range subtags[1:] {
+		if _, ok := _RTLScripts[subtag]; ok {
+			return true
+		}
+	}
+
+	return false
+}
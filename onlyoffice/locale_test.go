@@ -0,0 +1,60 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := map[string]string{
+		"en":         "en",
+		"en_us":      "en-US",
+		"EN-gb":      "en-GB",
+		"zh-hans-cn": "zh-Hans-CN",
+		"es-419":     "es-419",
+	}
+
+	for locale, expected := range tests {
+		if normalized, err := NormalizeLocale(locale); err != nil || normalized != expected {
+			t.Errorf("NormalizeLocale(%q) = %q, %v; expected %q", locale, normalized, err, expected)
+		}
+	}
+
+	for _, invalid := range []string{"", "e", "english", "en-US!", "1n"} {
+		if _, err := NormalizeLocale(invalid); !errors.Is(err, ErrInvalidLocale) {
+			t.Errorf("expected ErrInvalidLocale for %q, got %v", invalid, err)
+		}
+	}
+}
+
+func TestIsRTLLocale(t *testing.T) {
+	for _, locale := range []string{"ar", "ar-SA", "he", "he_IL", "fa-IR", "az-Arab"} {
+		if !IsRTLLocale(locale) {
+			t.Errorf("expected %q to be right-to-left", locale)
+		}
+	}
+
+	for _, locale := range []string{"en", "en-US", "ru", "az-Latn", "", "invalid locale"} {
+		if IsRTLLocale(locale) {
+			t.Errorf("expected %q to be left-to-right", locale)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	_ZipEOCDSignature = 0x06054b50
+	_ZipEOCDLength    = 22
+	_ZipMaxComment    = 0xFFFF
+	_ZipTailLength    = _ZipEOCDLength + _ZipMaxComment
+)
+
+// VerifyOOXMLIntegrity fetches the tail of an OOXML file and checks that the zip
+// End-Of-Central-Directory record is present and consistent with the file size.
+func (u fileUtility) VerifyOOXMLIntegrity(ctx context.Context, url string) error {
+	req, err := u.options.newRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=-%d", _ZipTailLength))
+
+	resp, err := u.options.do(HTTPPurposeDownload, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var tail []byte
+	var size int64
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if tail, err = io.ReadAll(io.LimitReader(resp.Body, _ZipTailLength)); err != nil {
+			return err
+		}
+
+		size = contentRangeSize(resp.Header.Get("Content-Range"))
+		if size < 0 {
+			size = int64(len(tail))
+		}
+	case http.StatusOK:
+		// The server ignored the Range header: stream the whole file, keeping
+		// only its tail in memory.
+		buffer := tailBuffer{limit: _ZipTailLength}
+		if size, err = io.Copy(&buffer, resp.Body); err != nil {
+			return err
+		}
+
+		tail = buffer.Bytes()
+	default:
+		return fmt.Errorf("%w: unexpected status %d", ErrCorruptDocument, resp.StatusCode)
+	}
+
+	return verifyZipTail(tail, size)
+}
+
+// tailBuffer is an io.Writer retaining the last limit bytes written to it.
+type tailBuffer struct {
+	buf   []byte
+	limit int
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > 2*t.limit {
+		t.buf = append(t.buf[:0], t.buf[len(t.buf)-t.limit:]...)
+	}
+
+	return len(p), nil
+}
+
+func (t *tailBuffer) Bytes() []byte {
+	return t.buf[max(0, len(t.buf)-t.limit):]
+}
+
+func verifyZipTail(tail []byte, size int64) error {
+	signature := make([]byte, 4)
+	binary.LittleEndian.PutUint32(signature, _ZipEOCDSignature)
+
+	pos := bytes.LastIndex(tail, signature)
+	if pos < 0 || len(tail)-pos < _ZipEOCDLength {
+		return ErrCorruptDocument
+	}
+
+	eocd := tail[pos:]
+	commentLength := int(binary.LittleEndian.Uint16(eocd[20:22]))
+	if pos+_ZipEOCDLength+commentLength != len(tail) {
+		return ErrCorruptDocument
+	}
+
+	directorySize := int64(binary.LittleEndian.Uint32(eocd[12:16]))
+	directoryOffset := int64(binary.LittleEndian.Uint32(eocd[16:20]))
+	if directorySize == 0xFFFFFFFF || directoryOffset == 0xFFFFFFFF {
+		return nil
+	}
+
+	eocdOffset := size - int64(len(tail)-pos)
+	if directoryOffset+directorySize != eocdOffset {
+		return ErrCorruptDocument
+	}
+
+	return nil
+}
+
+// contentRangeSize extracts the complete length from a "bytes 10-20/100" header, -1 when unknown.
+func contentRangeSize(contentRange string) int64 {
+	i := strings.LastIndex(contentRange, "/")
+	if i < 0 {
+		return -1
+	}
+
+	size, err := strconv.ParseInt(contentRange[i+1:], 10, 64)
+	if err != nil {
+		return -1
+	}
+
+	return size
+}
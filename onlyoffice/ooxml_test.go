@@ -0,0 +1,109 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestOOXML(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, name := range []string{"[Content_Types].xml", "word/document.xml"} {
+		file, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Write(bytes.Repeat([]byte("<w:p/>"), 2000))
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func newRangeServer(payload []byte, honorRange bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !honorRange {
+			w.Write(payload)
+			return
+		}
+
+		http.ServeContent(w, r, "document.docx", time.Time{}, bytes.NewReader(payload))
+	}))
+}
+
+func TestVerifyOOXMLIntegrity(t *testing.T) {
+	payload := newTestOOXML(t)
+	util := NewOnlyofficeFileUtility()
+
+	for _, honorRange := range []bool{true, false} {
+		intact := newRangeServer(payload, honorRange)
+		if err := util.VerifyOOXMLIntegrity(context.Background(), intact.URL); err != nil {
+			t.Errorf("expected an intact document (range %v), got %v", honorRange, err)
+		}
+		intact.Close()
+
+		truncated := newRangeServer(payload[:len(payload)-10], honorRange)
+		if err := util.VerifyOOXMLIntegrity(context.Background(), truncated.URL); !errors.Is(err, ErrCorruptDocument) {
+			t.Errorf("expected ErrCorruptDocument for a truncated document (range %v), got %v", honorRange, err)
+		}
+		truncated.Close()
+	}
+
+	prefix := append([]byte("garbage"), payload...)
+	shifted := newRangeServer(prefix, true)
+	defer shifted.Close()
+	if err := util.VerifyOOXMLIntegrity(context.Background(), shifted.URL); !errors.Is(err, ErrCorruptDocument) {
+		t.Errorf("expected ErrCorruptDocument for inconsistent offsets, got %v", err)
+	}
+}
+
+func TestVerifyOOXMLIntegrityLargeFileWithoutRange(t *testing.T) {
+	payload := newTestOOXML(t)
+	server := newRangeServer(payload, false)
+	defer server.Close()
+
+	util := NewOnlyofficeFileUtility(WithDefaultFileSizeLimit(int64(len(payload) / 2)))
+	if err := util.VerifyOOXMLIntegrity(context.Background(), server.URL); err != nil {
+		t.Errorf("expected a file over the size limit to be verified from its streamed tail, got %v", err)
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 100)
+	buffer := tailBuffer{limit: 25}
+	for i := 0; i < len(data); i += 7 {
+		buffer.Write(data[i:min(len(data), i+7)])
+	}
+
+	if !bytes.Equal(buffer.Bytes(), data[len(data)-25:]) || len(buffer.buf) > 2*25+7 {
+		t.Errorf("tailBuffer kept %q (%d bytes buffered); expected %q", buffer.Bytes(), len(buffer.buf), data[len(data)-25:])
+	}
+}
@@ -0,0 +1,296 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"net/http"
+	"slices"
+)
+
+const (
+	_DefaultFileSizeLimit = 100 << 20
+)
+
+// Options holds the configurable behaviour shared by the package utilities.
+type Options struct {
+	// HTTPClient, when set, is used for every outbound request that has no
+	// purpose-specific client in HTTPClients. When nil, requests use shared
+	// pooled clients tuned per HTTPPurpose.
+	HTTPClient *http.Client
+	// HTTPClients overrides the client used for a single HTTPPurpose.
+	HTTPClients map[HTTPPurpose]*http.Client
+	// UserAgent is sent with every outbound request.
+	UserAgent string
+	// BlockMacroExtensions makes macro-enabled formats (docm, xlsm, ...) unsupported.
+	BlockMacroExtensions bool
+	// ContentLengthHeaders are consulted in order by ValidateFileSize. Reverse
+	// proxies may report the real size in a header such as X-Original-Content-Length.
+	ContentLengthHeaders []string
+	// LenientExtensionMatching makes GetFileExt look past trailing junk such as
+	// "report.docx (1)" or "file.docx.bak" for a known extension.
+	LenientExtensionMatching bool
+	// EnforceDecompressedSize makes ValidateFileSize stream gzip-encoded files
+	// to measure their decompressed size instead of trusting the transfer size.
+	EnforceDecompressedSize bool
+	// DocumentTypeSizeLimits caps file sizes per document type in ValidateFileSizeAuto.
+	DocumentTypeSizeLimits map[string]int64
+	// ExtensionSizeLimits caps file sizes per extension in ValidateFileSizeAuto and
+	// takes precedence over DocumentTypeSizeLimits.
+	ExtensionSizeLimits map[string]int64
+	// DefaultFileSizeLimit applies to document types missing from DocumentTypeSizeLimits.
+	DefaultFileSizeLimit int64
+	// DocumentTypeOverrides maps extensions to document types, replacing the
+	// built-in type. Values are normalized with NormalizeDocumentType ("Word",
+	// "SPREADSHEET"); overrides with unknown values are ignored, so a built-in
+	// extension keeps its type, while GetFileType reports ErrUnknownDocumentType
+	// for an extension that is otherwise unsupported. Extensions missing from
+	// the built-in maps are added as view-only.
+	DocumentTypeOverrides map[string]string
+	// ExtensionSource replaces the built-in capability maps when set.
+	ExtensionSource ExtensionSource
+	// RequireHTTPS makes NormalizeServerURL, ValidateCallbackDownloadURL,
+	// WithCallbackURL and BuildConversionCallbackURL reject plain http urls.
+	// It is off by default for compatibility, but should be enabled in production.
+	RequireHTTPS bool
+	// TrustedHosts are storage hosts controlled by the integration. ValidateFileSize
+	// skips the HEAD request for urls on these hosts.
+	TrustedHosts []string
+	// CallbackHosts are the document server hosts callback download urls may
+	// point to. When empty, loopback, private and link-local hosts are rejected.
+	CallbackHosts []string
+	// DecodeLegacyFilenames makes EscapeFilename transcode names that are not
+	// valid UTF-8 but look like Windows-1251 (CP1251) to UTF-8.
+	DecodeLegacyFilenames bool
+	// AllowedOutputTypes restricts the conversion output types. Empty allows
+	// every output type the source can be converted to.
+	AllowedOutputTypes []string
+	// JWTManager signs the requests sent to the document server when set.
+	JWTManager OnlyofficeJWTManager
+	// ConcurrencyLimiter caps the outbound requests in flight. Share one limiter
+	// between utilities to enforce a global budget; nil means no limit.
+	ConcurrencyLimiter *ConcurrencyLimiter
+	// PDFForms marks pdf files as fillable. Enable it for Document Server 8.0
+	// and later, which fill forms in pdf files; older servers only fill oform.
+	PDFForms bool
+	// CancelAbandonedConversions makes ConvertAndWait and ConvertReader drop a
+	// conversion with a converter-generated key once the caller's context is done.
+	CancelAbandonedConversions bool
+}
+
+// Option configures Options.
+type Option func(*Options)
+
+// WithHTTPClient overrides the client used for outbound requests.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *Options) {
+		if client != nil {
+			o.HTTPClient = client
+		}
+	}
+}
+
+// WithHTTPClientFor overrides the client used for requests of the given purpose.
+func WithHTTPClientFor(purpose HTTPPurpose, client *http.Client) Option {
+	return func(o *Options) {
+		if client == nil {
+			return
+		}
+
+		if o.HTTPClients == nil {
+			o.HTTPClients = make(map[HTTPPurpose]*http.Client)
+		}
+
+		o.HTTPClients[purpose] = client
+	}
+}
+
+// WithUserAgent overrides the default onlyoffice-integration-adapters/<version> User-Agent.
+func WithUserAgent(userAgent string) Option {
+	return func(o *Options) {
+		if userAgent != "" {
+			o.UserAgent = userAgent
+		}
+	}
+}
+
+// WithBlockMacroExtensions rejects macro-enabled formats when block is true.
+func WithBlockMacroExtensions(block bool) Option {
+	return func(o *Options) {
+		o.BlockMacroExtensions = block
+	}
+}
+
+// WithContentLengthHeaders sets the header names consulted for the file size.
+func WithContentLengthHeaders(headers ...string) Option {
+	return func(o *Options) {
+		if len(headers) > 0 {
+			o.ContentLengthHeaders = headers
+		}
+	}
+}
+
+// WithLenientExtensionMatching enables lenient extension extraction in GetFileExt.
+func WithLenientExtensionMatching(lenient bool) Option {
+	return func(o *Options) {
+		o.LenientExtensionMatching = lenient
+	}
+}
+
+// WithEnforceDecompressedSize enables decompressed size checks for encoded responses.
+func WithEnforceDecompressedSize(enforce bool) Option {
+	return func(o *Options) {
+		o.EnforceDecompressedSize = enforce
+	}
+}
+
+// WithDocumentTypeSizeLimits sets per document type size limits (word, cell, slide).
+// Document types are normalized with NormalizeDocumentType, so "Spreadsheet" and
+// "CELL" are equivalent; unknown document types are ignored.
+func WithDocumentTypeSizeLimits(limits map[string]int64) Option {
+	return func(o *Options) {
+		o.DocumentTypeSizeLimits = make(map[string]int64, len(limits))
+		for docType, limit := range limits {
+			if normalized, err := NormalizeDocumentType(docType); err == nil {
+				o.DocumentTypeSizeLimits[normalized] = limit
+			}
+		}
+	}
+}
+
+// WithExtensionSizeLimits sets per extension size limits (txt, csv, pptx).
+// Extensions are normalized, so "TXT" and ".txt" are equivalent.
+func WithExtensionSizeLimits(limits map[string]int64) Option {
+	return func(o *Options) {
+		o.ExtensionSizeLimits = make(map[string]int64, len(limits))
+		for ext, limit := range limits {
+			o.ExtensionSizeLimits[normalizeExtension(ext)] = limit
+		}
+	}
+}
+
+// WithDefaultFileSizeLimit sets the size limit used when no specific limit matches.
+func WithDefaultFileSizeLimit(limit int64) Option {
+	return func(o *Options) {
+		if limit > 0 {
+			o.DefaultFileSizeLimit = limit
+		}
+	}
+}
+
+// WithDocumentTypeOverrides overrides the document type of extensions.
+func WithDocumentTypeOverrides(overrides map[string]string) Option {
+	return func(o *Options) {
+		o.DocumentTypeOverrides = make(map[string]string, len(overrides))
+		for ext, docType := range overrides {
+			o.DocumentTypeOverrides[ext] = docType
+		}
+	}
+}
+
+// WithExtensionSource classifies extensions with a custom source instead of the built-in maps.
+func WithExtensionSource(source ExtensionSource) Option {
+	return func(o *Options) {
+		o.ExtensionSource = source
+	}
+}
+
+// WithRequireHTTPS rejects plain http document server and callback urls.
+func WithRequireHTTPS(require bool) Option {
+	return func(o *Options) {
+		o.RequireHTTPS = require
+	}
+}
+
+// WithTrustedHosts skips the network size check for urls on the given hosts.
+func WithTrustedHosts(hosts ...string) Option {
+	return func(o *Options) {
+		o.TrustedHosts = append([]string(nil), hosts...)
+	}
+}
+
+// WithCallbackHosts restricts callback download urls to hosts.
+func WithCallbackHosts(hosts ...string) Option {
+	return func(o *Options) {
+		o.CallbackHosts = append(o.CallbackHosts, hosts...)
+	}
+}
+
+// WithDecodeLegacyFilenames transcodes likely Windows-1251 filenames to UTF-8 in EscapeFilename.
+func WithDecodeLegacyFilenames(decode bool) Option {
+	return func(o *Options) {
+		o.DecodeLegacyFilenames = decode
+	}
+}
+
+// WithAllowedOutputTypes restricts conversions to the given output types.
+func WithAllowedOutputTypes(outputTypes ...string) Option {
+	return func(o *Options) {
+		o.AllowedOutputTypes = make([]string, 0, len(outputTypes))
+		for _, outputType := range outputTypes {
+			o.AllowedOutputTypes = append(o.AllowedOutputTypes, normalizeExtension(outputType))
+		}
+	}
+}
+
+// WithJWTManager signs document server requests with the manager.
+func WithJWTManager(manager OnlyofficeJWTManager) Option {
+	return func(o *Options) {
+		o.JWTManager = manager
+	}
+}
+
+// WithConcurrencyLimiter makes outbound requests wait for a slot of limiter.
+func WithConcurrencyLimiter(limiter *ConcurrencyLimiter) Option {
+	return func(o *Options) {
+		o.ConcurrencyLimiter = limiter
+	}
+}
+
+// WithPDFForms marks pdf files as fillable, see Options.PDFForms.
+func WithPDFForms(enabled bool) Option {
+	return func(o *Options) {
+		o.PDFForms = enabled
+	}
+}
+
+// WithCancelAbandonedConversions cancels conversions with generated keys when
+// their caller gives up, see Options.CancelAbandonedConversions.
+func WithCancelAbandonedConversions(enabled bool) Option {
+	return func(o *Options) {
+		o.CancelAbandonedConversions = enabled
+	}
+}
+
+func (o Options) isOutputTypeAllowed(outputType string) bool {
+	return len(o.AllowedOutputTypes) == 0 || slices.Contains(o.AllowedOutputTypes, normalizeExtension(outputType))
+}
+
+func newOptions(opts ...Option) Options {
+	o := Options{
+		UserAgent:            _DefaultUserAgent,
+		ContentLengthHeaders: []string{"Content-Length"},
+		DefaultFileSizeLimit: _DefaultFileSizeLimit,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
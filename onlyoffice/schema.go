@@ -0,0 +1,147 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"unicode/utf8"
+)
+
+//go:embed config.schema.json
+var _ConfigSchemaJSON []byte
+
+// jsonSchema is the subset of JSON Schema used by config.schema.json.
+type jsonSchema struct {
+	Type       string                 `json:"type"`
+	Required   []string               `json:"required"`
+	Enum       []interface{}          `json:"enum"`
+	Properties map[string]*jsonSchema `json:"properties"`
+	Items      *jsonSchema            `json:"items"`
+	MinLength  *int                   `json:"minLength"`
+	MaxLength  *int                   `json:"maxLength"`
+}
+
+var _ConfigSchema = sync.OnceValue(func() *jsonSchema {
+	var schema jsonSchema
+	if err := json.Unmarshal(_ConfigSchemaJSON, &schema); err != nil {
+		panic(fmt.Sprintf("onlyoffice: invalid embedded config schema: %s", err))
+	}
+
+	return &schema
+})
+
+// ValidateSchema checks the structure of the config against the embedded JSON
+// schema of the editor config: required fields, value types and the allowed
+// values of mode, type and documentType. Every violation is reported in the
+// joined error, each wrapping ErrInvalidConfig.
+func (c *Config) ValidateSchema() error {
+	buf, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(buf, &value); err != nil {
+		return err
+	}
+
+	return errors.Join(_ConfigSchema().validate("config", value)...)
+}
+
+func (s *jsonSchema) validate(path string, value interface{}) []error {
+	invalid := func(format string, args ...interface{}) []error {
+		return []error{fmt.Errorf("%w: %s: %s", ErrInvalidConfig, path, fmt.Sprintf(format, args...))}
+	}
+
+	if s.Type != "" && !matchesSchemaType(s.Type, value) {
+		return invalid("expected %s", s.Type)
+	}
+
+	if len(s.Enum) > 0 && !containsValue(s.Enum, value) {
+		return invalid("%v is not one of %v", value, s.Enum)
+	}
+
+	var errs []error
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			errs = append(errs, invalid("shorter than %d characters", *s.MinLength)...)
+		}
+
+		if s.MaxLength != nil && length > *s.MaxLength {
+			errs = append(errs, invalid("longer than %d characters", *s.MaxLength)...)
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				errs = append(errs, invalid("missing required field %q", name)...)
+			}
+		}
+
+		for name, property := range s.Properties {
+			if field, ok := v[name]; ok {
+				errs = append(errs, property.validate(path+"."+name, field)...)
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				errs = append(errs, s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item)...)
+			}
+		}
+	}
+
+	return errs
+}
+
+func matchesSchemaType(schemaType string, value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return schemaType == "object"
+	case []interface{}:
+		return schemaType == "array"
+	case string:
+		return schemaType == "string"
+	case bool:
+		return schemaType == "boolean"
+	case float64:
+		return schemaType == "number" || (schemaType == "integer" && v == math.Trunc(v))
+	case nil:
+		return schemaType == "null"
+	default:
+		return false
+	}
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,55 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConfigValidateSchema(t *testing.T) {
+	config, err := BuildConfig("Report.docx", "https://storage.example.com/report.docx", "key", "docx",
+		WithRecent(RecentEntry{Title: "Budget.xlsx", URL: "https://app.example.com/budget"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := config.ValidateSchema(); err != nil {
+		t.Errorf("expected a built config to be valid, got %v", err)
+	}
+
+	config.EditorConfig.Mode = "write"
+	config.Type = "tablet"
+	config.Document.Key = ""
+	err = config.ValidateSchema()
+	if !errors.Is(err, ErrInvalidConfig) || !errors.Is(err, ErrOnlyofficeValidation) {
+		t.Fatalf("expected ErrInvalidConfig, got %v", err)
+	}
+
+	for _, field := range []string{"config.editorConfig.mode", "config.type", "config.document.key"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("expected %q to be reported, got %v", field, err)
+		}
+	}
+
+	if err := (&Config{DocumentType: "image"}).ValidateSchema(); err == nil || !strings.Contains(err.Error(), "config.documentType") {
+		t.Errorf("expected an invalid documentType to be reported, got %v", err)
+	}
+}
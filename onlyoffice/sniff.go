@@ -0,0 +1,304 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	_ContainerUnknown = ""
+	_ContainerZip     = "zip"
+	_ContainerODF     = "odf"
+	_ContainerOLE     = "ole"
+	_ContainerPDF     = "pdf"
+	_ContainerDjVu    = "djvu"
+	_ContainerRTF     = "rtf"
+)
+
+var _ContainerSignatures = []struct {
+	container string
+	signature []byte
+}{
+	{_ContainerZip, []byte("PK\x03\x04")},
+	{_ContainerOLE, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}},
+	{_ContainerPDF, []byte("%PDF-")},
+	{_ContainerDjVu, []byte("AT&TFORM")},
+	{_ContainerRTF, []byte(`{\rtf`)},
+}
+
+// _ExtensionContainers lists the container every binary format is stored in.
+// Text based formats are listed in _TextExtensions instead.
+var _ExtensionContainers = map[string]string{
+	"docx":  _ContainerZip,
+	"docxf": _ContainerZip,
+	"docm":  _ContainerZip,
+	"dotx":  _ContainerZip,
+	"dotm":  _ContainerZip,
+	"oform": _ContainerZip,
+	"xlsx":  _ContainerZip,
+	"xlsm":  _ContainerZip,
+	"xltx":  _ContainerZip,
+	"xltm":  _ContainerZip,
+	"xlsb":  _ContainerZip,
+	"pptx":  _ContainerZip,
+	"pptm":  _ContainerZip,
+	"ppsx":  _ContainerZip,
+	"ppsm":  _ContainerZip,
+	"potx":  _ContainerZip,
+	"potm":  _ContainerZip,
+	"epub":  _ContainerZip,
+	"xps":   _ContainerZip,
+	"oxps":  _ContainerZip,
+	"sxw":   _ContainerZip,
+	"stw":   _ContainerZip,
+	"sxc":   _ContainerZip,
+	"sxi":   _ContainerZip,
+	"odt":   _ContainerODF,
+	"ott":   _ContainerODF,
+	"ods":   _ContainerODF,
+	"ots":   _ContainerODF,
+	"odp":   _ContainerODF,
+	"otp":   _ContainerODF,
+	"doc":   _ContainerOLE,
+	"dot":   _ContainerOLE,
+	"xls":   _ContainerOLE,
+	"xlt":   _ContainerOLE,
+	"ppt":   _ContainerOLE,
+	"pot":   _ContainerOLE,
+	"pps":   _ContainerOLE,
+	"wps":   _ContainerOLE,
+	"wpt":   _ContainerOLE,
+	"et":    _ContainerOLE,
+	"ett":   _ContainerOLE,
+	"dps":   _ContainerOLE,
+	"dpt":   _ContainerOLE,
+	"pdf":   _ContainerPDF,
+	"djvu":  _ContainerDjVu,
+	"rtf":   _ContainerRTF,
+}
+
+// _TextExtensions lists the text based formats, which must not carry any of
+// the binary containers.
+var _TextExtensions = map[string]struct{}{
+	"csv": {}, "fb2": {}, "htm": {}, "html": {}, "mht": {}, "mhtml": {}, "txt": {},
+}
+
+var _ODFMimeTypes = map[string]string{
+	"application/vnd.oasis.opendocument.text":                  OnlyofficeWordType,
+	"application/vnd.oasis.opendocument.text-template":         OnlyofficeWordType,
+	"application/vnd.oasis.opendocument.spreadsheet":           OnlyofficeCellType,
+	"application/vnd.oasis.opendocument.spreadsheet-template":  OnlyofficeCellType,
+	"application/vnd.oasis.opendocument.presentation":          OnlyofficeSlideType,
+	"application/vnd.oasis.opendocument.presentation-template": OnlyofficeSlideType,
+}
+
+// sniffContainer detects the container of a file from its first bytes. ODF
+// packages are told apart from other zips by their leading mimetype entry,
+// whose document type is returned as well.
+func sniffContainer(head []byte) (container string, docType string) {
+	for _, candidate := range _ContainerSignatures {
+		if !bytes.HasPrefix(head, candidate.signature) {
+			continue
+		}
+
+		if candidate.container == _ContainerZip {
+			if docType, ok := sniffODFType(head); ok {
+				return _ContainerODF, docType
+			}
+		}
+
+		return candidate.container, ""
+	}
+
+	return _ContainerUnknown, ""
+}
+
+func sniffODFType(head []byte) (string, bool) {
+	if len(head) < 30 {
+		return "", false
+	}
+
+	nameLength := int(binary.LittleEndian.Uint16(head[26:28]))
+	extraLength := int(binary.LittleEndian.Uint16(head[28:30]))
+	if len(head) < 30+nameLength || string(head[30:30+nameLength]) != "mimetype" {
+		return "", false
+	}
+
+	content := head[min(len(head), 30+nameLength+extraLength):]
+	if end := bytes.Index(content, []byte("PK")); end >= 0 {
+		content = content[:end]
+	}
+
+	docType, ok := _ODFMimeTypes[strings.TrimSpace(string(content))]
+	return docType, ok
+}
+
+// ValidateUploadedFile compares the container sniffed from the first bytes of an
+// upload with the one expected for the claimed extension. OOXML formats share
+// the same zip container, so a docx claimed as xlsx is not detected, while ODF
+// packages are also checked against the claimed document type.
+func (u fileUtility) ValidateUploadedFile(ext string, head []byte) error {
+	ext = normalizeExtension(ext)
+	docType, err := u.GetFileType(ext)
+	if err != nil {
+		return err
+	}
+
+	container, sniffedType := sniffContainer(head)
+	if _, text := _TextExtensions[ext]; text {
+		if container != _ContainerUnknown {
+			return fmt.Errorf("%w: %s content claimed as %s", ErrFileContentMismatch, container, ext)
+		}
+
+		return nil
+	}
+
+	expected, known := _ExtensionContainers[ext]
+	if !known {
+		// Formats added by an ExtensionSource or overrides have no known layout.
+		return nil
+	}
+
+	if container != expected {
+		return fmt.Errorf("%w: %s content claimed as %s", ErrFileContentMismatch, containerName(container), ext)
+	}
+
+	if sniffedType != "" && sniffedType != docType {
+		return fmt.Errorf("%w: %s document claimed as %s", ErrFileContentMismatch, sniffedType, ext)
+	}
+
+	return nil
+}
+
+func containerName(container string) string {
+	if container == _ContainerUnknown {
+		return "unrecognized"
+	}
+
+	return container
+}
+
+const (
+	_TextSampleSize     = 64 << 10
+	_TextSampleMaxLines = 20
+	_TextMinRows        = 3
+	_TextDelimiters     = "\t,;|"
+)
+
+// DisambiguateText samples the first lines of a txt file and reports cell for
+// delimiter separated data, word otherwise. Only the first 64 KiB are requested.
+func (u fileUtility) DisambiguateText(ctx context.Context, url string) (string, error) {
+	req, err := u.options.newRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", _TextSampleSize-1))
+
+	resp, err := u.options.do(HTTPPurposeDownload, req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDownloadFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return "", fmt.Errorf("%w: unexpected status %d", ErrDownloadFailed, resp.StatusCode)
+	}
+
+	sample, err := io.ReadAll(io.LimitReader(resp.Body, _TextSampleSize))
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDownloadFailed, err)
+	}
+
+	if isDelimitedText(sample, len(sample) == _TextSampleSize) {
+		return OnlyofficeCellType, nil
+	}
+
+	return OnlyofficeWordType, nil
+}
+
+// isDelimitedText reports whether the sampled lines share a delimiter that
+// occurs the same, non-zero number of times on each of them. Two lines only
+// qualify with at least two delimiters each, so short prose with a comma per
+// line stays text. A truncated sample ignores its last, possibly partial, line.
+func isDelimitedText(sample []byte, truncated bool) bool {
+	lines := strings.Split(strings.ReplaceAll(string(sample), "\r\n", "\n"), "\n")
+	if truncated && len(lines) > 1 {
+		lines = lines[:len(lines)-1]
+	}
+
+	var rows []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		rows = append(rows, line)
+		if len(rows) == _TextSampleMaxLines {
+			break
+		}
+	}
+
+	if len(rows) < 2 {
+		return false
+	}
+
+	for _, delimiter := range _TextDelimiters {
+		expected := countUnquoted(rows[0], delimiter)
+		if expected == 0 || (expected < 2 && len(rows) < _TextMinRows) {
+			continue
+		}
+
+		consistent := true
+		for _, row := range rows[1:] {
+			if countUnquoted(row, delimiter) != expected {
+				consistent = false
+				break
+			}
+		}
+
+		if consistent {
+			return true
+		}
+	}
+
+	return false
+}
+
+// countUnquoted counts delimiter outside of double quoted fields.
+func countUnquoted(line string, delimiter rune) int {
+	count := 0
+	quoted := false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == delimiter && !quoted:
+			count++
+		}
+	}
+
+	return count
+}
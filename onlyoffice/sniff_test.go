@@ -0,0 +1,157 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestODF(t *testing.T, mimeType string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	file, err := writer.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte(mimeType))
+
+	content, err := writer.Create("content.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content.Write([]byte("<office:document-content/>"))
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestValidateUploadedFile(t *testing.T) {
+	util := NewOnlyofficeFileUtility()
+	ooxml := newTestOOXML(t)
+	odt := newTestODF(t, "application/vnd.oasis.opendocument.text")
+	ods := newTestODF(t, "application/vnd.oasis.opendocument.spreadsheet")
+	pdf := []byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n1 0 obj")
+	ole := []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1, 0x00}
+
+	tests := []struct {
+		name string
+		ext  string
+		head []byte
+		err  error
+	}{
+		{"docx as docx", "docx", ooxml, nil},
+		{"docx as xlsx shares the zip container", "xlsx", ooxml, nil},
+		{"odt as odt", "odt", odt, nil},
+		{"ods as ods", "ods", ods, nil},
+		{"odt as ods", "ods", odt, ErrFileContentMismatch},
+		{"odt as docx", "docx", odt, ErrFileContentMismatch},
+		{"docx as odt", "odt", ooxml, ErrFileContentMismatch},
+		{"pdf as pdf", "pdf", pdf, nil},
+		{"pdf as docx", "docx", pdf, ErrFileContentMismatch},
+		{"docx as pdf", "pdf", ooxml, ErrFileContentMismatch},
+		{"doc as doc", "doc", ole, nil},
+		{"doc as pdf", "pdf", ole, ErrFileContentMismatch},
+		{"text as txt", "txt", []byte("plain text"), nil},
+		{"pdf as txt", "txt", pdf, ErrFileContentMismatch},
+		{"xps as xps", "xps", ooxml, nil},
+		{"oxps as oxps", "oxps", ooxml, nil},
+		{"sxw as sxw", "sxw", ooxml, nil},
+		{"stw as stw", "stw", ooxml, nil},
+		{"sxc as sxc", "sxc", ooxml, nil},
+		{"sxi as sxi", "sxi", ooxml, nil},
+		{"pdf as xps", "xps", pdf, ErrFileContentMismatch},
+		{"text as sxw", "sxw", []byte("plain text"), ErrFileContentMismatch},
+		{"html as html", "html", []byte("<!DOCTYPE html>"), nil},
+		{"unsupported", "exe", []byte("MZ"), ErrOnlyofficeExtensionNotSupported},
+	}
+
+	for _, test := range tests {
+		if err := util.ValidateUploadedFile(test.ext, test.head[:min(len(test.head), 512)]); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestExtensionContainersCoverSupportedExtensions(t *testing.T) {
+	for ext := range NewOnlyofficeFileUtility().SupportedExtensionSet() {
+		_, binary := _ExtensionContainers[ext]
+		_, text := _TextExtensions[ext]
+		if binary == text {
+			t.Errorf("expected %q to be listed as exactly one of a binary or a text format", ext)
+		}
+	}
+}
+
+func TestDisambiguateText(t *testing.T) {
+	samples := map[string]string{
+		"name\tqty\tprice\napple\t3\t1.20\npear\t5\t0.90\n":                            OnlyofficeCellType,
+		"name,qty,note\r\napple,3,\"red, sweet\"\r\npear,5,green\r\n":                  OnlyofficeCellType,
+		"Dear team,\n\nthe report is ready. Please review it, and reply\nby Friday.\n": OnlyofficeWordType,
+		"single line, with a comma":                                                    OnlyofficeWordType,
+		"Hello, world\nGoodbye, moon":                                                  OnlyofficeWordType,
+		"name;qty;price\napple;3;1.20\n":                                               OnlyofficeCellType,
+		"":                                                                             OnlyofficeWordType,
+	}
+
+	util := NewOnlyofficeFileUtility()
+	for sample, expected := range samples {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(sample))
+		}))
+
+		docType, err := util.DisambiguateText(context.Background(), server.URL)
+		if err != nil || docType != expected {
+			t.Errorf("DisambiguateText(%q) = %q, %v; expected %q", sample, docType, err, expected)
+		}
+		server.Close()
+	}
+}
+
+func TestDisambiguateTextLargeSample(t *testing.T) {
+	rows := strings.Repeat("a;b;c\n", _TextSampleSize/4)
+	server := newRangeServer([]byte(rows), true)
+	defer server.Close()
+
+	docType, err := NewOnlyofficeFileUtility().DisambiguateText(context.Background(), server.URL)
+	if err != nil || docType != OnlyofficeCellType {
+		t.Errorf("DisambiguateText = %q, %v; expected %q", docType, err, OnlyofficeCellType)
+	}
+}
+
+func TestDisambiguateTextFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	if _, err := NewOnlyofficeFileUtility().DisambiguateText(context.Background(), server.URL); !errors.Is(err, ErrDownloadFailed) {
+		t.Errorf("expected ErrDownloadFailed, got %v", err)
+	}
+}
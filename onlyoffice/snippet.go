@@ -0,0 +1,95 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"encoding/json"
+	"html/template"
+	"strconv"
+	"strings"
+)
+
+const (
+	_OnlyofficeAPIScriptPath       = "/web-apps/apps/api/documents/api.js"
+	_OnlyofficeLegacyAPIScriptPath = "/OfficeWeb/apps/api/documents/api.js"
+	_OnlyofficeWebAppsMajorVersion = 4
+	_OnlyofficeEditorElementID     = "onlyoffice-editor"
+)
+
+// APIScriptURL returns the api.js url of a document server. Servers before 4.0
+// served the editor from /OfficeWeb, later ones from /web-apps. An unknown or
+// unparsable version is assumed to be current.
+func APIScriptURL(serverURL string, info ServerInfo) string {
+	base := strings.TrimRight(strings.TrimSpace(serverURL), "/")
+	major, _, _ := strings.Cut(strings.TrimSpace(info.Version), ".")
+	if n, err := strconv.Atoi(major); err == nil && n < _OnlyofficeWebAppsMajorVersion {
+		return base + _OnlyofficeLegacyAPIScriptPath
+	}
+
+	return base + _OnlyofficeAPIScriptPath
+}
+
+var _EditorSnippetTemplate = template.Must(template.New("editor").Parse(`<div id="{{.ElementID}}"></div>
+<script type="text/javascript" src="{{.ScriptURL}}"></script>
+<script type="text/javascript">
+new DocsAPI.DocEditor({{.ElementID}}, {{.Config}});
+</script>
+`))
+
+// RenderEditorSnippet renders the markup embedding the editor: a placeholder
+// element, the document server api.js and the DocsAPI.DocEditor call with the
+// config inlined as JSON. When jwt is not nil the config is signed first,
+// replacing any token; otherwise an existing token, such as the one set by
+// BuildShareConfig, is kept. cfg itself is not modified. Every interpolated
+// value is escaped for its context.
+func RenderEditorSnippet(serverURL string, cfg *Config, jwt OnlyofficeJWTManager) (string, error) {
+	normalized, err := NormalizeServerURL(serverURL)
+	if err != nil {
+		return "", err
+	}
+
+	config := *cfg
+	if jwt != nil {
+		config.Token = ""
+		if config.Token, err = jwt.Sign(config); err != nil {
+			return "", err
+		}
+	}
+
+	buf, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+
+	var snippet strings.Builder
+	if err := _EditorSnippetTemplate.Execute(&snippet, struct {
+		ElementID string
+		ScriptURL string
+		Config    template.JS
+	}{
+		ElementID: _OnlyofficeEditorElementID,
+		ScriptURL: APIScriptURL(normalized, ServerInfo{}),
+		// json.Marshal escapes <, > and & so the config cannot close the script element.
+		Config: template.JS(buf),
+	}); err != nil {
+		return "", err
+	}
+
+	return snippet.String(), nil
+}
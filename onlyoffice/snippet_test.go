@@ -0,0 +1,128 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var _snippetConfigPattern = regexp.MustCompile(`DocEditor\("onlyoffice-editor", (.*)\);`)
+
+func TestRenderEditorSnippet(t *testing.T) {
+	manager := NewOnlyofficeJWTManager("secret")
+	title := `</script><script>alert("x")</script>.docx`
+	config, err := BuildConfig(title, "https://storage.example.com/report.docx", "key", "docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snippet, err := RenderEditorSnippet("https://docs.example.com/", config, manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(snippet, `src="https://docs.example.com/web-apps/apps/api/documents/api.js"`) {
+		t.Errorf("expected the api.js script, got %s", snippet)
+	}
+
+	if strings.Contains(snippet, "<script>alert") || strings.Count(snippet, "</script>") != 2 {
+		t.Errorf("expected the title to be escaped, got %s", snippet)
+	}
+
+	if config.Token != "" {
+		t.Errorf("expected the config not to be modified, got token %q", config.Token)
+	}
+
+	match := _snippetConfigPattern.FindStringSubmatch(snippet)
+	if match == nil {
+		t.Fatalf("expected an inlined config, got %s", snippet)
+	}
+
+	var inlined Config
+	if err := json.Unmarshal([]byte(match[1]), &inlined); err != nil {
+		t.Fatalf("expected valid JSON, got %v", err)
+	}
+
+	if inlined.Document.Title != config.Document.Title {
+		t.Errorf("expected title %q, got %q", config.Document.Title, inlined.Document.Title)
+	}
+
+	var signed Config
+	if err := manager.Verify(inlined.Token, &signed); err != nil {
+		t.Fatalf("expected a valid token, got %v", err)
+	}
+
+	if signed.Document.Key != "key" || signed.Document.Title != config.Document.Title {
+		t.Errorf("unexpected signed config %+v", signed.Document)
+	}
+
+	if _, err := RenderEditorSnippet("javascript:alert(1)", config, manager); err == nil {
+		t.Error("expected an invalid server url to be rejected")
+	}
+}
+
+func TestRenderEditorSnippetPresigned(t *testing.T) {
+	manager := NewOnlyofficeJWTManager("secret")
+	config, err := BuildShareConfig("report.docx", "https://storage.example.com/report.docx", "key", "docx", manager, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snippet, err := RenderEditorSnippet("https://docs.example.com", config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	match := _snippetConfigPattern.FindStringSubmatch(snippet)
+	if match == nil {
+		t.Fatalf("expected an inlined config, got %s", snippet)
+	}
+
+	var inlined Config
+	if err := json.Unmarshal([]byte(match[1]), &inlined); err != nil {
+		t.Fatalf("expected valid JSON, got %v", err)
+	}
+
+	if inlined.Token == "" || inlined.Token != config.Token {
+		t.Errorf("expected the existing token to be kept, got %q", inlined.Token)
+	}
+}
+
+func TestAPIScriptURL(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"7.3.3.50", "https://docs.example.com/web-apps/apps/api/documents/api.js"},
+		{"4.0.0", "https://docs.example.com/web-apps/apps/api/documents/api.js"},
+		{"3.8.1", "https://docs.example.com/OfficeWeb/apps/api/documents/api.js"},
+		{"", "https://docs.example.com/web-apps/apps/api/documents/api.js"},
+		{"unknown", "https://docs.example.com/web-apps/apps/api/documents/api.js"},
+	}
+
+	for _, test := range tests {
+		if url := APIScriptURL("https://docs.example.com/", ServerInfo{Version: test.version}); url != test.expected {
+			t.Errorf("APIScriptURL(%q) = %q; expected %q", test.version, url, test.expected)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+// ExtensionSource classifies extensions, replacing the built-in capability maps.
+// It allows loading the mapping from a JSON file or a remote config service.
+// Lookup receives normalized (lowercase, dot-less) extensions.
+type ExtensionSource interface {
+	Lookup(ext string) (docType string, capability Capability, ok bool)
+}
+
+// ExtensionLister is optionally implemented by an ExtensionSource that can
+// enumerate its extensions. Such sources are indexed once when the utility is
+// created; otherwise every lookup is delegated to the source and the
+// enumerating methods (SupportedExtensionSet, ExtensionsByType, ...) only see
+// extensions added through overrides.
+type ExtensionLister interface {
+	Extensions() []string
+}
+
+// buildSourceIndex indexes the extensions of a source implementing ExtensionLister.
+func buildSourceIndex(source ExtensionSource) map[string]extensionEntry {
+	index := make(map[string]extensionEntry)
+	lister, ok := source.(ExtensionLister)
+	if !ok {
+		return index
+	}
+
+	for _, ext := range lister.Extensions() {
+		ext = normalizeExtension(ext)
+		if docType, capability, ok := source.Lookup(ext); ok && capability != CapabilityUnsupported {
+			index[ext] = extensionEntry{docType: docType, capability: capability}
+		}
+	}
+
+	return index
+}
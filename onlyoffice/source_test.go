@@ -0,0 +1,82 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"errors"
+	"testing"
+)
+
+type testExtensionSource map[string]extensionEntry
+
+func (s testExtensionSource) Lookup(ext string) (string, Capability, bool) {
+	entry, ok := s[ext]
+	return entry.docType, entry.capability, ok
+}
+
+type testListingExtensionSource struct {
+	testExtensionSource
+}
+
+func (s testListingExtensionSource) Extensions() []string {
+	extensions := make([]string, 0, len(s.testExtensionSource))
+	for ext := range s.testExtensionSource {
+		extensions = append(extensions, ext)
+	}
+
+	return extensions
+}
+
+func TestExtensionSource(t *testing.T) {
+	source := testExtensionSource{
+		"vsdx": {docType: "diagram", capability: CapabilityViewOnly},
+		"docx": {docType: OnlyofficeWordType, capability: CapabilityEditable},
+	}
+
+	for name, util := range map[string]OnlyofficeFileUtility{
+		"lookup":  NewOnlyofficeFileUtility(WithExtensionSource(source)),
+		"listing": NewOnlyofficeFileUtility(WithExtensionSource(testListingExtensionSource{source})),
+	} {
+		docType, err := util.GetFileType(".VSDX")
+		if err != nil || docType != "diagram" {
+			t.Errorf("%s: expected the custom diagram type, got %q, %v", name, docType, err)
+		}
+
+		if !util.IsExtensionViewOnly("vsdx") || !util.IsExtensionEditable("docx") {
+			t.Errorf("%s: expected the custom capabilities", name)
+		}
+
+		if _, err := util.GetFileType("xlsx"); !errors.Is(err, ErrOnlyofficeExtensionNotSupported) {
+			t.Errorf("%s: expected the built-in maps to be replaced, got %v", name, err)
+		}
+
+		if info, err := util.Describe("vsdx"); err != nil || info.DocumentType != "diagram" {
+			t.Errorf("%s: unexpected description %+v, %v", name, info, err)
+		}
+	}
+
+	listed := NewOnlyofficeFileUtility(WithExtensionSource(testListingExtensionSource{source})).SupportedExtensionSet()
+	if _, ok := listed["vsdx"]; !ok || len(listed) != 2 {
+		t.Errorf("expected a listing source to be indexed, got %v", listed)
+	}
+
+	if docType, _ := NewOnlyofficeFileUtility().GetFileType("docx"); docType != OnlyofficeWordType {
+		t.Error("expected the built-in maps by default")
+	}
+}
@@ -0,0 +1,67 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+const _TitleMaxLength = 255
+
+// collapseText drops control and format characters and collapses whitespace runs into single spaces.
+func collapseText(text string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return ' '
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+			return -1
+		default:
+			return r
+		}
+	}, text)
+
+	return strings.Join(strings.Fields(cleaned), " ")
+}
+
+// NormalizeTitle prepares an untrusted document title for the editor config:
+// control characters are removed, whitespace is collapsed and titles longer
+// than 255 characters are shortened while keeping the extension.
+// ErrInvalidTitle is returned when nothing is left.
+func NormalizeTitle(title string) (string, error) {
+	normalized := collapseText(title)
+	if normalized == "" {
+		return "", ErrInvalidTitle
+	}
+
+	runes := []rune(normalized)
+	if len(runes) <= _TitleMaxLength {
+		return normalized, nil
+	}
+
+	ext := []rune(filepath.Ext(normalized))
+	if len(ext) >= _TitleMaxLength/2 {
+		ext = nil
+	}
+
+	base := strings.TrimSpace(string(runes[:_TitleMaxLength-len(ext)]))
+	return base + string(ext), nil
+}
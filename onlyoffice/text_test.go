@@ -0,0 +1,55 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := map[string]string{
+		"Report.docx":                    "Report.docx",
+		"  Quarterly \t\n  Report.docx ": "Quarterly Report.docx",
+		"Re\x00port\x1b\u200b.docx":      "Report.docx",
+	}
+
+	for title, expected := range tests {
+		if normalized, err := NormalizeTitle(title); err != nil || normalized != expected {
+			t.Errorf("NormalizeTitle(%q) = %q, %v; expected %q", title, normalized, err, expected)
+		}
+	}
+
+	long, err := NormalizeTitle(strings.Repeat("я", 400) + ".docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if utf8.RuneCountInString(long) != _TitleMaxLength || !strings.HasSuffix(long, ".docx") {
+		t.Errorf("expected a %d characters title keeping the extension, got %d: %q", _TitleMaxLength, utf8.RuneCountInString(long), long)
+	}
+
+	for _, empty := range []string{"", "   ", "\x00\x01\t"} {
+		if _, err := NormalizeTitle(empty); !errors.Is(err, ErrInvalidTitle) {
+			t.Errorf("expected ErrInvalidTitle for %q, got %v", empty, err)
+		}
+	}
+}
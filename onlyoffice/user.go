@@ -0,0 +1,65 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	_AnonymousUserIDPrefix = "anon-"
+	_GuestNamePrefix       = "Guest-"
+	_GuestNameSuffixLength = 4
+	_UserNameMaxLength     = 128
+	_DefaultUserName       = "User"
+)
+
+// AnonymousUserID derives a stable user id for a guest session from a seed
+// such as the session id. The same seed always yields the same id, different
+// seeds yield distinct ids with 128 bits of collision resistance.
+func AnonymousUserID(sessionSeed string) string {
+	sum := sha256.Sum256([]byte("onlyoffice-anonymous-user:" + sessionSeed))
+	return _AnonymousUserIDPrefix + hex.EncodeToString(sum[:16])
+}
+
+// GuestName returns the display name of the guest identified by sessionSeed,
+// "Guest-" followed by four characters of its AnonymousUserID.
+func GuestName(sessionSeed string) string {
+	id := strings.TrimPrefix(AnonymousUserID(sessionSeed), _AnonymousUserIDPrefix)
+	return _GuestNamePrefix + strings.ToUpper(id[:_GuestNameSuffixLength])
+}
+
+// NormalizeUserName prepares an untrusted display name for the editor config:
+// control characters are removed, whitespace is collapsed and names longer than
+// 128 characters are cut. Names left empty fall back to "User".
+func NormalizeUserName(name string) string {
+	normalized := collapseText(name)
+	if normalized == "" {
+		return _DefaultUserName
+	}
+
+	runes := []rune(normalized)
+	if len(runes) <= _UserNameMaxLength {
+		return normalized
+	}
+
+	return strings.TrimSpace(string(runes[:_UserNameMaxLength]))
+}
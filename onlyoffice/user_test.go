@@ -0,0 +1,81 @@
+/**
+ *
+ * (c) Copyright Ascensio System SIA 2023
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package onlyoffice
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var _guestNamePattern = regexp.MustCompile(`^Guest-[0-9A-F]{4}$`)
+
+func TestAnonymousUserID(t *testing.T) {
+	id := AnonymousUserID("session-1")
+	if id != AnonymousUserID("session-1") || GuestName("session-1") != GuestName("session-1") {
+		t.Error("expected the id and name to be deterministic")
+	}
+
+	if !_guestNamePattern.MatchString(GuestName("session-1")) {
+		t.Errorf("unexpected guest name %q", GuestName("session-1"))
+	}
+
+	seen := make(map[string]struct{})
+	for i := 0; i < 1000; i++ {
+		id := AnonymousUserID("session-" + strconv.Itoa(i))
+		if _, ok := seen[id]; ok {
+			t.Fatalf("duplicate id %q", id)
+		}
+
+		seen[id] = struct{}{}
+	}
+
+	if AnonymousUserID("") == AnonymousUserID(" ") {
+		t.Error("expected distinct seeds to yield distinct ids")
+	}
+}
+
+func TestNormalizeUserName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"John Smith":             "John Smith",
+		"  John \t\n  Smith ":    "John Smith",
+		"Jo\x00hn\x1b[31m":       "John[31m",
+		"Ann\u200b\u00a0Lee":     "Ann Lee",
+		"":                       "User",
+		" \t\x07 ":               "User",
+		strings.Repeat("я", 200): strings.Repeat("я", 128),
+	} {
+		if normalized := NormalizeUserName(name); normalized != expected {
+			t.Errorf("NormalizeUserName(%q) = %q; expected %q", name, normalized, expected)
+		}
+	}
+}
+
+func TestWithUserNormalizesName(t *testing.T) {
+	config, err := BuildConfig("Report.docx", "https://storage.example.com/report.docx", "key", "docx",
+		WithUser("1", "\x00 Jane\tDoe "))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user := config.EditorConfig.User; user.ID != "1" || user.Name != "Jane Doe" {
+		t.Errorf("WithUser set %+v; expected id 1 and name %q", user, "Jane Doe")
+	}
+}